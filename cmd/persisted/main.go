@@ -0,0 +1,52 @@
+// Command persisted builds the persisted operations manifest served with
+// the persisted_operations section of config.yaml. It extracts every named
+// operation from the .graphql and .gql files under the given directories
+// (default: the current directory):
+//
+//	go run ./cmd/persisted -o persisted-operations.json examples
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/natnael_wondwoesn/GGStarter/graph/persisted"
+)
+
+func main() {
+	output := flag.String("o", "", "manifest file to write (default: stdout)")
+	flag.Parse()
+
+	if err := run(*output, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "persisted:", err)
+		os.Exit(1)
+	}
+}
+
+func run(output string, dirs []string) error {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	manifest, err := persisted.Extract(dirs...)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if output == "" || output == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d operations to %s\n", len(manifest), output)
+	return nil
+}
@@ -2,52 +2,396 @@
 package config
 
 import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
 	"github.com/spf13/viper"
 )
 
+// EnvPrefix prefixes the environment variable of every key, with dots
+// replaced by underscores: server.port is APP_SERVER_PORT.
+const EnvPrefix = "APP"
+
 type Config struct {
-    Server   ServerConfig
-    Database DatabaseConfig
-    JWT      JWTConfig
+	Server              ServerConfig
+	HTTP                HTTPConfig
+	Database            DatabaseConfig
+	JWT                 JWTConfig
+	BootstrapAdmin      BootstrapAdminConfig `mapstructure:"bootstrap_admin"`
+	GraphQL             GraphQLConfig
+	Metrics             MetricsConfig
+	Tracing             TracingConfig
+	Redis               RedisConfig
+	Cache               CacheConfig
+	RateLimit           RateLimitConfig           `mapstructure:"rate_limit"`
+	PersistedOperations PersistedOperationsConfig `mapstructure:"persisted_operations"`
+	Uploads             UploadsConfig
+	IDE                 IDEConfig
 }
 
 type ServerConfig struct {
-    Port string
-    Mode string // development, production
+	Port         string
+	Mode         string        // development, production
+	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
+	// ShutdownTimeout is how long in-flight requests may drain after
+	// SIGTERM or SIGINT before connections are closed forcibly.
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// DrainDelay keeps serving after a shutdown signal, with readiness
+	// failing, so load balancers stop routing before the listener closes.
+	// It should exceed the readiness probe period times its failure
+	// threshold; DrainDelay plus ShutdownTimeout must fit in the platform's
+	// termination grace period (30s on Kubernetes by default).
+	DrainDelay time.Duration `mapstructure:"drain_delay"`
+}
+
+// HTTPConfig configures the middleware wrapped around every route.
+type HTTPConfig struct {
+	RequestIDHeader string `mapstructure:"request_id_header"`
+	// TrustProxyHeaders takes the client IP from X-Forwarded-For, X-Real-IP
+	// or True-Client-IP. Only enable it behind a proxy that sets them.
+	TrustProxyHeaders bool  `mapstructure:"trust_proxy_headers"`
+	MaxBodyBytes      int64 `mapstructure:"max_body_bytes"` // 0 disables the limit
+	CORS              CORSConfig
+	SecurityHeaders   SecurityHeadersConfig `mapstructure:"security_headers"`
+}
+
+// CORSConfig also decides which cross-origin pages may open a WebSocket.
+type CORSConfig struct {
+	Enabled          bool
+	AllowedOrigins   []string      `mapstructure:"allowed_origins"` // "*" allows any origin, without credentials
+	AllowedMethods   []string      `mapstructure:"allowed_methods"`
+	AllowedHeaders   []string      `mapstructure:"allowed_headers"`
+	AllowCredentials bool          `mapstructure:"allow_credentials"`
+	MaxAge           time.Duration `mapstructure:"max_age"`
+}
+
+type SecurityHeadersConfig struct {
+	Enabled               bool
+	ContentSecurityPolicy string        `mapstructure:"content_security_policy"`
+	FrameOptions          string        `mapstructure:"frame_options"`
+	ReferrerPolicy        string        `mapstructure:"referrer_policy"`
+	HSTSMaxAge            time.Duration `mapstructure:"hsts_max_age"` // 0 omits Strict-Transport-Security
 }
 
 type DatabaseConfig struct {
-    Host     string
-    Port     string
-    User     string
-    Password string
-    Name     string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
 }
 
 type JWTConfig struct {
-    Secret     string
-    Expiration int // in hours
+	Secret            string
+	Expiration        int // in hours
+	RefreshExpiration int `mapstructure:"refresh_expiration"` // in hours
+	Issuer            string
+	Audience          string
+	// JWKSFile is an optional path to a JSON Web Key Set whose RSA keys
+	// are accepted for RS256 tokens alongside HS256 tokens signed with Secret.
+	JWKSFile string `mapstructure:"jwks_file"`
+}
+
+// BootstrapAdminConfig creates an admin at startup unless a user with Email
+// already exists. Registration only creates regular users, so this is how
+// the first admin is made. Empty Email disables it.
+type BootstrapAdminConfig struct {
+	Email    string
+	Password string
+	Name     string
 }
 
-// LoadConfig reads configuration from file or environment variables
+// GraphQLConfig bounds the cost of a single operation. Zero disables a limit.
+type GraphQLConfig struct {
+	MaxDepth      int `mapstructure:"max_depth"`
+	MaxComplexity int `mapstructure:"max_complexity"`
+	MaxAliases    int `mapstructure:"max_aliases"`
+	MaxRootFields int `mapstructure:"max_root_fields"`
+	// SlowResolverThreshold logs resolvers that take at least this long.
+	SlowResolverThreshold time.Duration `mapstructure:"slow_resolver_threshold"`
+}
+
+type MetricsConfig struct {
+	Enabled            bool
+	Path               string
+	ResolverSampleRate float64 `mapstructure:"resolver_sample_rate"` // 0 to 1
+	// OperationNames are recorded as the operation label, as are the
+	// operations of the persisted operations manifest. Any other name is
+	// recorded as "other" so clients cannot create unbounded series.
+	OperationNames []string `mapstructure:"operation_names"`
+}
+
+type TracingConfig struct {
+	Exporter          string  // none, stdout, file or otlp
+	File              string  // output path for the file exporter
+	Endpoint          string  // host:port of an OTLP/HTTP collector
+	ServiceName       string  `mapstructure:"service_name"`
+	SampleRatio       float64 `mapstructure:"sample_ratio"` // 0 to 1
+	SkipTrivialFields bool    `mapstructure:"skip_trivial_fields"`
+}
+
+// CacheConfig sizes the caches in front of query parsing.
+type CacheConfig struct {
+	Query    QueryCacheConfig
+	APQ      APQCacheConfig
+	Response ResponseCacheConfig
+}
+
+// QueryCacheConfig caches parsed and validated documents in memory.
+// Documents reference the in-process schema, and each replica validates
+// the queries it serves itself, so this cache is never shared.
+type QueryCacheConfig struct {
+	Size int // entries
+}
+
+// APQCacheConfig stores automatic persisted queries. Use the redis backend
+// so that a hash registered on one replica is found on every other.
+type APQCacheConfig struct {
+	Backend   string        // memory or redis
+	Size      int           // entries; must be positive for memory, 0 is unbounded for redis
+	TTL       time.Duration // redis only; 0 never expires
+	KeyPrefix string        `mapstructure:"key_prefix"` // redis only
+}
+
+// ResponseCacheConfig caches whole query responses in memory for the
+// maxAge of their @cacheControl policy.
+type ResponseCacheConfig struct {
+	Enabled bool
+	Size    int // entries
+}
+
+type RedisConfig struct {
+	Addr     string // host:port
+	Password string
+	DB       int
+}
+
+// RateLimitConfig throttles operations per client with token buckets. A
+// client is identified by user ID when authenticated, then by one of
+// APIKeys, then by IP.
+type RateLimitConfig struct {
+	Enabled      bool
+	Backend      string // memory or redis
+	KeyPrefix    string `mapstructure:"key_prefix"` // for the redis backend
+	APIKeyHeader string `mapstructure:"api_key_header"`
+	// APIKeys are the keys given the APIKey rate. Any other key is ignored
+	// and its client limited by IP.
+	APIKeys []string `mapstructure:"api_keys"`
+	// ComplexityUnit charges one token per this much query complexity,
+	// rounded up. Zero charges one token per operation.
+	ComplexityUnit int `mapstructure:"complexity_unit"`
+	User           RateLimitRate
+	APIKey         RateLimitRate `mapstructure:"api_key"`
+	IP             RateLimitRate
+}
+
+// RateLimitRate refills Requests tokens every Period into a bucket holding
+// at most Burst. Zero Requests disables limiting for that kind of client.
+type RateLimitRate struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// PersistedOperationsConfig restricts clients to the operations listed in a
+// manifest of SHA-256 hashes to documents.
+type PersistedOperationsConfig struct {
+	Enabled  bool
+	Manifest string
+	// Strict rejects any document not in the manifest. It is always on in
+	// production mode.
+	Strict    bool
+	HotReload bool `mapstructure:"hot_reload"`
+}
+
+// UploadsConfig configures multipart file uploads and where their content
+// is stored.
+type UploadsConfig struct {
+	// MaxSize caps a whole multipart request. It replaces http.max_body_bytes
+	// for uploads, and is at most math.MaxInt32 so that every file's size
+	// fits Attachment.size.
+	MaxSize int64 `mapstructure:"max_size"`
+	// MaxMemory is how much of a request is buffered in memory before
+	// spilling to temporary files.
+	MaxMemory int64 `mapstructure:"max_memory"`
+	// AllowedTypes lists MIME types, or prefixes such as "image/*", checked
+	// against the sniffed content type.
+	AllowedTypes []string `mapstructure:"allowed_types"`
+	Storage      string   // local or s3
+	Local        LocalStorageConfig
+	S3           S3StorageConfig
+}
+
+type LocalStorageConfig struct {
+	// Dir holds stored files; uploads are staged in "<dir>.tmp" on the same
+	// filesystem.
+	Dir       string
+	URLPrefix string `mapstructure:"url_prefix"` // where files are served, such as /files/
+	// URLSecret signs download URLs. Empty uses a random secret per
+	// process, so URLs stop working on restart and across replicas.
+	URLSecret string        `mapstructure:"url_secret"`
+	URLExpiry time.Duration `mapstructure:"url_expiry"` // lifetime of signed download URLs
+}
+
+type S3StorageConfig struct {
+	Endpoint  string // host[:port], without a scheme
+	Region    string
+	Bucket    string
+	AccessKey string        `mapstructure:"access_key"`
+	SecretKey string        `mapstructure:"secret_key"`
+	UseSSL    bool          `mapstructure:"use_ssl"`
+	URLExpiry time.Duration `mapstructure:"url_expiry"` // lifetime of presigned download URLs
+}
+
+// IDEConfig configures the in-browser GraphQL IDE.
+type IDEConfig struct {
+	// Kind is playground, graphiql, sandbox or off. Empty serves graphiql
+	// in development and nothing in production.
+	Kind  string
+	Path  string
+	Title string
+	// Headers pre-fill the IDE's headers editor, for example with a
+	// development token.
+	Headers map[string]string
+	// ExamplesDir holds .graphql files opened as example tabs.
+	ExamplesDir string `mapstructure:"examples_dir"`
+}
+
+// LoadConfig reads configuration from defaults, then the optional
+// config.yaml in path, then environment variables such as
+// APP_DATABASE_HOST. Variables in path/.env are loaded first for local
+// development; they never override variables already set.
 func LoadConfig(path string) (*Config, error) {
-    viper.AddConfigPath(path)
-    viper.SetConfigName("config")
-    viper.SetConfigType("yaml")
-
-    viper.AutomaticEnv() // override with env variables
-
-    // Set defaults
-    viper.SetDefault("server.port", "8080")
-    viper.SetDefault("server.mode", "development")
-    viper.SetDefault("jwt.expiration", 24)
-    
-    err := viper.ReadInConfig()
-    if err != nil {
-        return nil, err
-    }
-
-    var config Config
-    err = viper.Unmarshal(&config)
-    return &config, err
-}
\ No newline at end of file
+	if err := loadDotEnv(filepath.Join(path, ".env")); err != nil {
+		return nil, err
+	}
+
+	viper.AddConfigPath(path)
+	viper.SetConfigName("config")
+	viper.SetConfigType("yaml")
+
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+	// AutomaticEnv only sees keys viper already knows from defaults or the
+	// config file, so bind every key explicitly.
+	bindEnv(reflect.TypeOf(Config{}), "")
+	// Platforms such as Cloud Run and Heroku assign the port in PORT.
+	viper.BindEnv("server.port", EnvPrefix+"_SERVER_PORT", "PORT")
+
+	// Set defaults
+	viper.SetDefault("server.port", "8080")
+	viper.SetDefault("server.mode", "development")
+	viper.SetDefault("server.read_timeout", "10s")
+	viper.SetDefault("server.write_timeout", "30s")
+	viper.SetDefault("server.idle_timeout", "120s")
+	viper.SetDefault("server.shutdown_timeout", "20s")
+	viper.SetDefault("server.drain_delay", "5s")
+	viper.SetDefault("http.request_id_header", "X-Request-ID")
+	viper.SetDefault("http.trust_proxy_headers", false)
+	viper.SetDefault("http.max_body_bytes", 1<<20)
+	viper.SetDefault("http.cors.enabled", true)
+	viper.SetDefault("http.cors.allowed_origins", []string{"*"})
+	viper.SetDefault("http.cors.allowed_methods", []string{"GET", "POST", "OPTIONS"})
+	viper.SetDefault("http.cors.allowed_headers", []string{"Authorization", "Content-Type", "X-Request-ID", "Apollographql-Client-Name", "X-Client-Name"})
+	viper.SetDefault("http.cors.allow_credentials", false)
+	viper.SetDefault("http.cors.max_age", "10m")
+	viper.SetDefault("http.security_headers.enabled", true)
+	viper.SetDefault("http.security_headers.frame_options", "DENY")
+	viper.SetDefault("http.security_headers.referrer_policy", "no-referrer")
+	viper.SetDefault("http.security_headers.hsts_max_age", "0s")
+	viper.SetDefault("jwt.expiration", 24)
+	viper.SetDefault("jwt.refresh_expiration", 24*30)
+	viper.SetDefault("bootstrap_admin.name", "Admin")
+	viper.SetDefault("graphql.max_depth", 10)
+	viper.SetDefault("graphql.max_complexity", 1000)
+	viper.SetDefault("graphql.max_aliases", 30)
+	viper.SetDefault("graphql.max_root_fields", 20)
+	viper.SetDefault("graphql.slow_resolver_threshold", "200ms")
+	viper.SetDefault("metrics.enabled", true)
+	viper.SetDefault("metrics.path", "/metrics")
+	viper.SetDefault("metrics.resolver_sample_rate", 0.1)
+	viper.SetDefault("metrics.operation_names", []string{})
+	viper.SetDefault("tracing.exporter", "none")
+	viper.SetDefault("tracing.service_name", "ggstarter")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("tracing.skip_trivial_fields", true)
+	viper.SetDefault("redis.addr", "localhost:6379")
+	viper.SetDefault("cache.query.size", 1000)
+	viper.SetDefault("cache.apq.backend", "memory")
+	viper.SetDefault("cache.apq.size", 100)
+	viper.SetDefault("cache.apq.ttl", "24h")
+	viper.SetDefault("cache.apq.key_prefix", "apq:")
+	viper.SetDefault("cache.response.enabled", false)
+	viper.SetDefault("cache.response.size", 1000)
+	viper.SetDefault("rate_limit.enabled", true)
+	viper.SetDefault("rate_limit.backend", "memory")
+	viper.SetDefault("rate_limit.key_prefix", "ratelimit:")
+	viper.SetDefault("rate_limit.api_key_header", "X-API-Key")
+	viper.SetDefault("rate_limit.api_keys", []string{})
+	viper.SetDefault("rate_limit.complexity_unit", 100)
+	viper.SetDefault("rate_limit.user.requests", 300)
+	viper.SetDefault("rate_limit.user.period", "1m")
+	viper.SetDefault("rate_limit.user.burst", 100)
+	viper.SetDefault("rate_limit.api_key.requests", 1200)
+	viper.SetDefault("rate_limit.api_key.period", "1m")
+	viper.SetDefault("rate_limit.api_key.burst", 300)
+	viper.SetDefault("rate_limit.ip.requests", 60)
+	viper.SetDefault("rate_limit.ip.period", "1m")
+	viper.SetDefault("rate_limit.ip.burst", 30)
+	viper.SetDefault("persisted_operations.enabled", false)
+	viper.SetDefault("persisted_operations.manifest", "persisted-operations.json")
+	viper.SetDefault("persisted_operations.strict", false)
+	viper.SetDefault("persisted_operations.hot_reload", true)
+	viper.SetDefault("uploads.max_size", 10<<20)
+	viper.SetDefault("uploads.max_memory", 1<<20)
+	viper.SetDefault("uploads.allowed_types", []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"})
+	viper.SetDefault("uploads.storage", "local")
+	viper.SetDefault("uploads.local.dir", "uploads")
+	viper.SetDefault("uploads.local.url_prefix", "/files/")
+	viper.SetDefault("uploads.local.url_expiry", "15m")
+	viper.SetDefault("uploads.s3.region", "us-east-1")
+	viper.SetDefault("uploads.s3.use_ssl", true)
+	viper.SetDefault("uploads.s3.url_expiry", "15m")
+	viper.SetDefault("ide.kind", "")
+	viper.SetDefault("ide.path", "/")
+	viper.SetDefault("ide.title", "GraphQL IDE")
+	viper.SetDefault("ide.examples_dir", "")
+
+	err := viper.ReadInConfig()
+	var notFound viper.ConfigFileNotFoundError
+	if err != nil && !errors.As(err, &notFound) {
+		return nil, err
+	}
+
+	var config Config
+	err = viper.Unmarshal(&config)
+	return &config, err
+}
+
+// bindEnv binds the key of every leaf field of t, named as viper names it
+// from the mapstructure tag or the lowercased field name, under prefix.
+func bindEnv(t reflect.Type, prefix string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.ToLower(f.Name)
+		if tag := f.Tag.Get("mapstructure"); tag != "" {
+			name = tag
+		}
+		key := prefix + name
+
+		switch {
+		case f.Type.Kind() == reflect.Struct:
+			bindEnv(f.Type, key+".")
+		case f.Type.Kind() == reflect.Map:
+			// A single variable cannot hold a map.
+		default:
+			viper.BindEnv(key)
+		}
+	}
+}
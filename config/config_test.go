@@ -0,0 +1,136 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// load runs LoadConfig on a directory holding files, named by path, with a
+// fresh viper instance.
+func load(t *testing.T, files map[string]string) *Config {
+	t.Helper()
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	return cfg
+}
+
+// unsetEnv unsets keys for the rest of the test, restoring them after.
+func unsetEnv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func TestEnvironmentOverridesConfigFile(t *testing.T) {
+	unsetEnv(t, "PORT")
+	t.Setenv("APP_SERVER_PORT", "9090")
+	t.Setenv("APP_DATABASE_HOST", "db.internal")
+	// Keys without a default or a value in config.yaml are bound too.
+	t.Setenv("APP_UPLOADS_S3_BUCKET", "attachments")
+	t.Setenv("APP_HTTP_CORS_ALLOWED_ORIGINS", "http://a.example,http://b.example")
+
+	cfg := load(t, map[string]string{"config.yaml": "server:\n  port: \"8080\"\ndatabase:\n  host: localhost\n"})
+	if cfg.Server.Port != "9090" {
+		t.Errorf("server.port = %q, want 9090", cfg.Server.Port)
+	}
+	if cfg.Database.Host != "db.internal" {
+		t.Errorf("database.host = %q, want db.internal", cfg.Database.Host)
+	}
+	if cfg.Uploads.S3.Bucket != "attachments" {
+		t.Errorf("uploads.s3.bucket = %q, want attachments", cfg.Uploads.S3.Bucket)
+	}
+	if got := cfg.HTTP.CORS.AllowedOrigins; len(got) != 2 || got[0] != "http://a.example" || got[1] != "http://b.example" {
+		t.Errorf("http.cors.allowed_origins = %q, want both origins", got)
+	}
+}
+
+func TestPortFallback(t *testing.T) {
+	unsetEnv(t, "APP_SERVER_PORT")
+	t.Setenv("PORT", "7070")
+	if cfg := load(t, nil); cfg.Server.Port != "7070" {
+		t.Errorf("server.port = %q, want PORT's 7070", cfg.Server.Port)
+	}
+
+	t.Setenv("APP_SERVER_PORT", "9090")
+	if cfg := load(t, nil); cfg.Server.Port != "9090" {
+		t.Errorf("server.port = %q, want APP_SERVER_PORT's 9090 over PORT", cfg.Server.Port)
+	}
+}
+
+func TestDotEnv(t *testing.T) {
+	keys := []string{"APP_JWT_SECRET", "APP_DATABASE_PASSWORD", "APP_DATABASE_USER", "APP_DATABASE_NAME", "APP_DATABASE_HOST", "APP_SERVER_MODE", "PORT", "APP_SERVER_PORT"}
+	unsetEnv(t, keys...)
+	t.Setenv("APP_DATABASE_HOST", "from-environment")
+
+	cfg := load(t, map[string]string{".env": `
+# A full-line comment.
+APP_JWT_SECRET="a \"quoted\" secret # not a comment"
+APP_DATABASE_PASSWORD='p@ss $word # literal'
+export APP_DATABASE_USER=exported
+APP_DATABASE_NAME = spaced   # trailing comment
+APP_DATABASE_HOST=from-dotenv
+APP_SERVER_MODE=
+`})
+
+	for key, tt := range map[string]struct{ got, want string }{
+		"jwt.secret":        {cfg.JWT.Secret, `a "quoted" secret # not a comment`},
+		"database.password": {cfg.Database.Password, "p@ss $word # literal"},
+		"database.user":     {cfg.Database.User, "exported"},
+		"database.name":     {cfg.Database.Name, "spaced"},
+		"database.host":     {cfg.Database.Host, "from-environment"},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", key, tt.got, tt.want)
+		}
+	}
+	if mode, set := os.LookupEnv("APP_SERVER_MODE"); !set || mode != "" {
+		t.Errorf("APP_SERVER_MODE = %q, %v; want set and empty", mode, set)
+	}
+}
+
+func TestDotEnvRejectsMalformedLines(t *testing.T) {
+	unsetEnv(t, "APP_JWT_SECRET", "APP_DATABASE_PASSWORD")
+	for name, content := range map[string]string{
+		"missing =":           "APP_JWT_SECRET\n",
+		"missing key":         "=value\n",
+		"unterminated double": "APP_JWT_SECRET=\"open\n",
+		"unterminated single": "APP_DATABASE_PASSWORD='open\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			if err := os.WriteFile(path, []byte("# first line\n"+content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			err := loadDotEnv(path)
+			if err == nil {
+				t.Fatal("loadDotEnv accepted the file")
+			}
+			if !strings.Contains(err.Error(), ".env:2:") {
+				t.Errorf("error %q does not name line 2", err)
+			}
+		})
+	}
+}
+
+func TestDotEnvIsOptional(t *testing.T) {
+	if err := loadDotEnv(filepath.Join(t.TempDir(), ".env")); err != nil {
+		t.Errorf("missing .env: %v", err)
+	}
+}
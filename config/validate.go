@@ -0,0 +1,234 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinProductionSecretLength is the shortest JWT secret accepted in
+// production: 32 bytes, the output size of HMAC-SHA256.
+const MinProductionSecretLength = 32
+
+// MinPasswordLength is the shortest password accepted for an account,
+// including the bootstrap admin's.
+const MinPasswordLength = 8
+
+// Development defaults that must not reach production. They are the values
+// shipped in config.yaml.
+const (
+	developmentJWTSecret        = "dev-secret-change-me"
+	developmentDatabasePassword = "postgres"
+)
+
+// ValidationError lists every problem found in a Config.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks c and returns a *ValidationError listing every problem,
+// each naming the key to fix, or nil. Production mode adds rules against
+// weak secrets and development defaults.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.oneOf("server.mode", c.Server.Mode, "development", "production")
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		v.addf("server.port must be a number from 1 to 65535, got %q", c.Server.Port)
+	}
+	v.nonNegative("server.read_timeout", c.Server.ReadTimeout)
+	v.nonNegative("server.write_timeout", c.Server.WriteTimeout)
+	v.nonNegative("server.idle_timeout", c.Server.IdleTimeout)
+	v.positive("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	v.nonNegative("server.drain_delay", c.Server.DrainDelay)
+
+	if c.HTTP.MaxBodyBytes < 0 {
+		v.addf("http.max_body_bytes must not be negative, got %d", c.HTTP.MaxBodyBytes)
+	}
+
+	if c.HTTP.CORS.AllowCredentials && slices.Contains(c.HTTP.CORS.AllowedOrigins, "*") {
+		v.addf("http.cors.allow_credentials cannot be combined with the \"*\" origin; list the allowed origins")
+	}
+
+	if c.JWT.Secret == "" {
+		v.addf("jwt.secret is required to sign access tokens")
+	}
+	if c.JWT.Expiration <= 0 {
+		v.addf("jwt.expiration must be a positive number of hours, got %d", c.JWT.Expiration)
+	}
+	if c.JWT.RefreshExpiration <= 0 {
+		v.addf("jwt.refresh_expiration must be a positive number of hours, got %d", c.JWT.RefreshExpiration)
+	}
+
+	if c.BootstrapAdmin.Email != "" {
+		switch n := len(c.BootstrapAdmin.Password); {
+		case n == 0:
+			v.addf("bootstrap_admin.password is required when bootstrap_admin.email is set")
+		case n < MinPasswordLength:
+			v.addf("bootstrap_admin.password must be at least %d characters, got %d", MinPasswordLength, n)
+		case n > 72:
+			v.addf("bootstrap_admin.password must be at most 72 bytes, the bcrypt limit, got %d", n)
+		}
+	}
+
+	for key, n := range map[string]int{
+		"graphql.max_depth":       c.GraphQL.MaxDepth,
+		"graphql.max_complexity":  c.GraphQL.MaxComplexity,
+		"graphql.max_aliases":     c.GraphQL.MaxAliases,
+		"graphql.max_root_fields": c.GraphQL.MaxRootFields,
+	} {
+		if n < 0 {
+			v.addf("%s must not be negative (0 disables the limit), got %d", key, n)
+		}
+	}
+
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		v.addf("metrics.path must start with /, got %q", c.Metrics.Path)
+	}
+	v.ratio("metrics.resolver_sample_rate", c.Metrics.ResolverSampleRate)
+
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, "", "none", "stdout", "file", "otlp")
+	if c.Tracing.Exporter == "file" && c.Tracing.File == "" {
+		v.addf("tracing.file is required by the file exporter")
+	}
+	if c.Tracing.Exporter == "otlp" && c.Tracing.Endpoint == "" {
+		v.addf("tracing.endpoint is required by the otlp exporter")
+	}
+	v.ratio("tracing.sample_ratio", c.Tracing.SampleRatio)
+
+	if c.Cache.Query.Size <= 0 {
+		v.addf("cache.query.size must be positive, got %d", c.Cache.Query.Size)
+	}
+	// The memory APQ backend needs a positive size; redis takes 0 as
+	// unbounded.
+	v.oneOf("cache.apq.backend", c.Cache.APQ.Backend, "memory", "redis")
+	switch c.Cache.APQ.Backend {
+	case "memory":
+		if c.Cache.APQ.Size <= 0 {
+			v.addf("cache.apq.size must be positive for the memory backend, got %d", c.Cache.APQ.Size)
+		}
+	case "redis":
+		if c.Cache.APQ.Size < 0 {
+			v.addf("cache.apq.size must not be negative (0 is unbounded for redis), got %d", c.Cache.APQ.Size)
+		}
+		if c.Cache.APQ.KeyPrefix == "" {
+			v.addf("cache.apq.key_prefix is required by the redis backend")
+		}
+		v.nonNegative("cache.apq.ttl", c.Cache.APQ.TTL)
+	}
+	if c.Cache.Response.Enabled && c.Cache.Response.Size <= 0 {
+		v.addf("cache.response.size must be positive when the response cache is enabled, got %d", c.Cache.Response.Size)
+	}
+
+	if c.RateLimit.Enabled {
+		v.oneOf("rate_limit.backend", c.RateLimit.Backend, "memory", "redis")
+		for key, r := range map[string]RateLimitRate{
+			"rate_limit.user":    c.RateLimit.User,
+			"rate_limit.api_key": c.RateLimit.APIKey,
+			"rate_limit.ip":      c.RateLimit.IP,
+		} {
+			if r.Requests > 0 && r.Period <= 0 {
+				v.addf("%s.period must be positive when %s.requests is set", key, key)
+			}
+		}
+	}
+
+	if c.PersistedOperations.Enabled && c.PersistedOperations.Manifest == "" {
+		v.addf("persisted_operations.manifest is required when persisted operations are enabled")
+	}
+
+	// Attachment.size is a GraphQL Int, which is 32 bits.
+	if c.Uploads.MaxSize <= 0 || c.Uploads.MaxSize > math.MaxInt32 {
+		v.addf("uploads.max_size must be from 1 to %d bytes, got %d", math.MaxInt32, c.Uploads.MaxSize)
+	}
+	v.oneOf("uploads.storage", c.Uploads.Storage, "local", "s3")
+	switch c.Uploads.Storage {
+	case "local":
+		if c.Uploads.Local.Dir == "" {
+			v.addf("uploads.local.dir is required by local storage")
+		}
+		if !strings.HasPrefix(c.Uploads.Local.URLPrefix, "/") {
+			v.addf("uploads.local.url_prefix must start with /, got %q", c.Uploads.Local.URLPrefix)
+		}
+		v.nonNegative("uploads.local.url_expiry", c.Uploads.Local.URLExpiry)
+	case "s3":
+		if c.Uploads.S3.Endpoint == "" {
+			v.addf("uploads.s3.endpoint is required by s3 storage")
+		}
+		if c.Uploads.S3.Bucket == "" {
+			v.addf("uploads.s3.bucket is required by s3 storage")
+		}
+	}
+
+	v.oneOf("ide.kind", c.IDE.Kind, "", "off", "graphiql", "playground", "sandbox")
+	if !strings.HasPrefix(c.IDE.Path, "/") {
+		v.addf("ide.path must start with /, got %q", c.IDE.Path)
+	}
+
+	if c.Server.Mode == "production" {
+		switch {
+		case c.JWT.Secret == developmentJWTSecret:
+			v.addf("jwt.secret is the development default; set a random value of at least %d bytes", MinProductionSecretLength)
+		case c.JWT.Secret != "" && len(c.JWT.Secret) < MinProductionSecretLength:
+			v.addf("jwt.secret must be at least %d bytes in production, got %d", MinProductionSecretLength, len(c.JWT.Secret))
+		}
+		switch c.Database.Password {
+		case "":
+			v.addf("database.password is required in production")
+		case developmentDatabasePassword:
+			v.addf("database.password is the development default; set the production password")
+		}
+	}
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	slices.Sort(v.problems)
+	return &ValidationError{Problems: v.problems}
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	if slices.Contains(allowed, value) {
+		return
+	}
+	var named []string
+	for _, a := range allowed {
+		if a != "" {
+			named = append(named, a)
+		}
+	}
+	v.addf("%s must be one of %s, got %q", key, strings.Join(named, ", "), value)
+}
+
+func (v *validator) nonNegative(key string, d time.Duration) {
+	if d < 0 {
+		v.addf("%s must not be negative, got %s", key, d)
+	}
+}
+
+func (v *validator) positive(key string, d time.Duration) {
+	if d <= 0 {
+		v.addf("%s must be positive, got %s", key, d)
+	}
+}
+
+func (v *validator) ratio(key string, f float64) {
+	if f < 0 || f > 1 {
+		v.addf("%s must be between 0 and 1, got %g", key, f)
+	}
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// validConfig returns the defaults with the settings they lack, which
+// passes Validate in both modes.
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg := load(t, nil)
+	cfg.JWT.Secret = strings.Repeat("s", MinProductionSecretLength)
+	cfg.Database.Password = "production-password"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("base config: %v", err)
+	}
+	return cfg
+}
+
+// problems returns the problems Validate reports for cfg.
+func problems(t *testing.T, cfg *Config) []string {
+	t.Helper()
+	err := cfg.Validate()
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate returned %T, want *ValidationError", err)
+	}
+	return verr.Problems
+}
+
+func TestValidateReportsEveryProblemSorted(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Server.Mode = "staging"
+	cfg.Server.Port = "0"
+	cfg.JWT.Expiration = 0
+	cfg.Cache.APQ.Backend = "redis"
+	cfg.Cache.APQ.KeyPrefix = ""
+	cfg.Uploads.Storage = "s3"
+
+	got := problems(t, cfg)
+	want := []string{
+		`cache.apq.key_prefix is required by the redis backend`,
+		`jwt.expiration must be a positive number of hours, got 0`,
+		`server.mode must be one of development, production, got "staging"`,
+		`server.port must be a number from 1 to 65535, got "0"`,
+		`uploads.s3.bucket is required by s3 storage`,
+		`uploads.s3.endpoint is required by s3 storage`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	err := cfg.Validate()
+	if msg := err.Error(); !strings.HasPrefix(msg, "invalid configuration:\n  - cache.apq.key_prefix") {
+		t.Errorf("error message %q does not list the problems", msg)
+	}
+}
+
+func TestValidateProduction(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"development JWT secret", func(c *Config) { c.JWT.Secret = developmentJWTSecret }, "jwt.secret is the development default"},
+		{"short JWT secret", func(c *Config) { c.JWT.Secret = "short" }, "jwt.secret must be at least 32 bytes in production, got 5"},
+		{"missing JWT secret", func(c *Config) { c.JWT.Secret = "" }, "jwt.secret is required"},
+		{"missing database password", func(c *Config) { c.Database.Password = "" }, "database.password is required in production"},
+		{"default database password", func(c *Config) { c.Database.Password = developmentDatabasePassword }, "database.password is the development default"},
+		{"admin without password", func(c *Config) {
+			c.BootstrapAdmin.Email = "admin@example.com"
+		}, "bootstrap_admin.password is required"},
+		{"short admin password", func(c *Config) {
+			c.BootstrapAdmin.Email = "admin@example.com"
+			c.BootstrapAdmin.Password = "short"
+		}, "bootstrap_admin.password must be at least 8 characters, got 5"},
+		{"admin password over the bcrypt limit", func(c *Config) {
+			c.BootstrapAdmin.Email = "admin@example.com"
+			c.BootstrapAdmin.Password = strings.Repeat("p", 73)
+		}, "bootstrap_admin.password must be at most 72 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			cfg.Server.Mode = "production"
+			tt.modify(cfg)
+
+			got := problems(t, cfg)
+			if len(got) != 1 || !strings.HasPrefix(got[0], tt.want) {
+				t.Errorf("problems %q, want one starting %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateUploadSizeFitsAttachmentSize(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Uploads.MaxSize = math.MaxInt32
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("uploads.max_size of 2 GiB - 1: %v", err)
+	}
+
+	for _, size := range []int64{0, math.MaxInt32 + 1} {
+		cfg.Uploads.MaxSize = size
+		got := problems(t, cfg)
+		if len(got) != 1 || !strings.HasPrefix(got[0], "uploads.max_size must be from 1 to 2147483647 bytes") {
+			t.Errorf("uploads.max_size %d: problems %q", size, got)
+		}
+	}
+}
+
+func TestValidateAllowsDevelopmentDefaultsInDevelopment(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.JWT.Secret = developmentJWTSecret
+	cfg.Database.Password = developmentDatabasePassword
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("development defaults rejected in development: %v", err)
+	}
+
+	cfg.BootstrapAdmin.Email = "admin@example.com"
+	cfg.BootstrapAdmin.Password = strings.Repeat("p", MinPasswordLength)
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("admin password of %d characters rejected: %v", MinPasswordLength, err)
+	}
+}
+
+func TestValidateRejectsCredentialsWithAnyOrigin(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.HTTP.CORS.AllowCredentials = true
+	cfg.HTTP.CORS.AllowedOrigins = []string{"https://app.example.com"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("credentials with a listed origin: %v", err)
+	}
+
+	cfg.HTTP.CORS.AllowedOrigins = []string{"https://app.example.com", "*"}
+	got := problems(t, cfg)
+	if len(got) != 1 || !strings.HasPrefix(got[0], "http.cors.allow_credentials cannot be combined") {
+		t.Errorf("problems %q", got)
+	}
+}
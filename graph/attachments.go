@@ -0,0 +1,47 @@
+package graph
+
+import (
+	"context"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+)
+
+// attachmentKey is where the content of a is stored: under its todo and
+// its own ID so names never collide, keeping a sanitized filename last for
+// the download name.
+func attachmentKey(a *model.Attachment) string {
+	return a.TodoID + "/" + a.ID + "/" + sanitizeFilename(a.Filename)
+}
+
+// sanitizeFilename reduces a client-supplied filename to a single safe path
+// element.
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r)), r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	name = strings.TrimLeft(name, ".")
+	if name == "" {
+		return "file"
+	}
+	return name
+}
+
+// deleteFiles removes the stored content of attachments. Failures are
+// ignored: the records are already gone and an orphaned blob is harmless.
+func (r *Resolver) deleteFiles(ctx context.Context, attachments []*model.Attachment) {
+	if r.Files == nil {
+		return
+	}
+	for _, a := range attachments {
+		_ = r.Files.Delete(ctx, a.Key)
+	}
+}
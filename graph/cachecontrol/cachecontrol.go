@@ -0,0 +1,307 @@
+// Package cachecontrol computes an HTTP cache policy for each query from the
+// @cacheControl hints in the schema, sets Cache-Control on GET responses and
+// optionally caches whole responses.
+package cachecontrol
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const extensionName = "CacheControl"
+
+// Scope says whether a response may be stored by shared caches.
+type Scope string
+
+const (
+	ScopePublic  Scope = "PUBLIC"
+	ScopePrivate Scope = "PRIVATE"
+)
+
+// Policy is the cache policy of an operation. A zero MaxAge is uncacheable.
+type Policy struct {
+	MaxAge int // seconds
+	Scope  Scope
+	// Types are the object types the response contains, used as tags to
+	// invalidate cached responses.
+	Types []string
+}
+
+// Cacheable reports whether the response may be cached at all.
+func (p Policy) Cacheable() bool {
+	return p.MaxAge > 0
+}
+
+// Header returns the Cache-Control header value for p.
+func (p Policy) Header() string {
+	if !p.Cacheable() {
+		return "no-store"
+	}
+	scope := "public"
+	if p.Scope == ScopePrivate {
+		scope = "private"
+	}
+	return "max-age=" + strconv.Itoa(p.MaxAge) + ", " + scope
+}
+
+// Extension is a gqlgen handler extension computing the Policy of every
+// query. It must be installed for Middleware and ResponseCache to apply.
+type Extension struct {
+	schema *ast.Schema
+}
+
+var (
+	_ graphql.HandlerExtension        = (*Extension)(nil)
+	_ graphql.OperationContextMutator = (*Extension)(nil)
+	_ graphql.ResponseInterceptor     = (*Extension)(nil)
+)
+
+func (e *Extension) ExtensionName() string {
+	return extensionName
+}
+
+func (e *Extension) Validate(schema graphql.ExecutableSchema) error {
+	e.schema = schema.Schema()
+	return nil
+}
+
+func (e *Extension) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	policy := Policy{}
+	if opCtx.Operation.Operation == ast.Query {
+		policy = e.policyOf(opCtx.Operation.SelectionSet, opCtx.Variables)
+	}
+	opCtx.Stats.SetExtension(extensionName, &policy)
+	return nil
+}
+
+// InterceptResponse hands the policy to Middleware once the operation has
+// produced a response. Responses with errors are marked uncacheable, and
+// when a later OperationContextMutator rejected the operation, Middleware
+// is told to set no Cache-Control at all.
+func (e *Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	h := holderFor(ctx)
+	if !Executed(ctx) {
+		if h != nil {
+			h.refuse()
+		}
+		return next(ctx)
+	}
+
+	resp := next(ctx)
+	if h == nil || resp == nil {
+		return resp
+	}
+	if policy := PolicyFor(ctx); policy != nil && len(resp.Errors) == 0 {
+		h.set(*policy)
+	} else {
+		h.set(Policy{})
+	}
+	return resp
+}
+
+// Executed reports whether the response being intercepted comes from
+// executing the operation. It is false when gqlgen dispatches the errors of
+// a request that failed before execution, such as one rejected by an
+// OperationContextMutator; those errors are already recorded when the
+// response interceptors run.
+func Executed(ctx context.Context) bool {
+	return len(graphql.GetErrors(ctx)) == 0
+}
+
+// PolicyFor returns the policy of the current operation, or nil when the
+// extension is not installed.
+func PolicyFor(ctx context.Context) *Policy {
+	if !graphql.HasOperationContext(ctx) {
+		return nil
+	}
+	p, _ := graphql.GetOperationContext(ctx).Stats.GetExtension(extensionName).(*Policy)
+	return p
+}
+
+func (e *Extension) policyOf(set ast.SelectionSet, vars map[string]any) Policy {
+	w := walker{schema: e.schema, vars: vars, maxAge: -1, scope: ScopePublic, types: map[string]bool{}}
+	w.walk(set, 0, true)
+
+	policy := Policy{MaxAge: max(w.maxAge, 0), Scope: w.scope}
+	for t := range w.types {
+		policy.Types = append(policy.Types, t)
+	}
+	sort.Strings(policy.Types)
+	return policy
+}
+
+type walker struct {
+	schema *ast.Schema
+	vars   map[string]any
+	maxAge int // -1 until a field is seen
+	scope  Scope
+	types  map[string]bool
+}
+
+func (w *walker) walk(set ast.SelectionSet, parentMaxAge int, root bool) {
+	for _, sel := range set {
+		switch s := sel.(type) {
+		case *ast.Field:
+			w.field(s, parentMaxAge, root)
+		case *ast.InlineFragment:
+			w.walk(s.SelectionSet, parentMaxAge, root)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				w.walk(s.Definition.SelectionSet, parentMaxAge, root)
+			}
+		}
+	}
+}
+
+func (w *walker) field(f *ast.Field, parentMaxAge int, root bool) {
+	if f.Definition == nil || f.Name == "__typename" {
+		return
+	}
+	if f.Name == "__schema" || f.Name == "__type" {
+		// Introspection is never cached.
+		w.apply(0, "")
+		return
+	}
+
+	typ := w.schema.Types[f.Definition.Type.Name()]
+	composite := typ != nil && typ.IsCompositeType()
+
+	maxAge, scope, ok := w.hint(f.Definition.Directives)
+	if !ok && composite {
+		maxAge, scope, ok = w.hint(typ.Directives)
+	}
+	if !ok {
+		maxAge = parentMaxAge
+		if root {
+			maxAge = 0
+		}
+	}
+	w.apply(maxAge, scope)
+
+	if composite {
+		w.types[typ.Name] = true
+		w.walk(f.SelectionSet, maxAge, false)
+	}
+}
+
+// hint reads @cacheControl from dirs. A hint with only a scope keeps the
+// default maxAge of zero.
+func (w *walker) hint(dirs ast.DirectiveList) (int, Scope, bool) {
+	d := dirs.ForName("cacheControl")
+	if d == nil {
+		return 0, "", false
+	}
+	maxAge := 0
+	if arg := d.Arguments.ForName("maxAge"); arg != nil {
+		if v, err := arg.Value.Value(w.vars); err == nil {
+			if n, ok := v.(int64); ok {
+				maxAge = int(n)
+			}
+		}
+	}
+	var scope Scope
+	if arg := d.Arguments.ForName("scope"); arg != nil {
+		scope = Scope(arg.Value.Raw)
+	}
+	return maxAge, scope, true
+}
+
+func (w *walker) apply(maxAge int, scope Scope) {
+	if w.maxAge < 0 || maxAge < w.maxAge {
+		w.maxAge = maxAge
+	}
+	if scope == ScopePrivate {
+		w.scope = ScopePrivate
+	}
+}
+
+// holder carries the policy from the extension to the HTTP middleware.
+type holder struct {
+	mu      sync.Mutex
+	policy  *Policy
+	refused bool // the operation was rejected before execution
+}
+
+func (h *holder) set(p Policy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.policy = &p
+}
+
+func (h *holder) refuse() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.refused = true
+}
+
+// get returns the policy to send, or nil when there is none or the
+// operation was refused.
+func (h *holder) get() *Policy {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.refused {
+		return nil
+	}
+	return h.policy
+}
+
+type ctxKey struct{}
+
+func holderFor(ctx context.Context) *holder {
+	h, _ := ctx.Value(ctxKey{}).(*holder)
+	return h
+}
+
+// Middleware sets Cache-Control on GET responses from the operation's
+// policy. POST responses are not cacheable by HTTP caches and are left
+// alone, as are responses written before the policy is known, such as the
+// errors of a rejected operation.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := &holder{}
+		r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, h))
+		next.ServeHTTP(&headerWriter{ResponseWriter: w, holder: h}, r)
+	})
+}
+
+type headerWriter struct {
+	http.ResponseWriter
+	holder      *holder
+	wroteHeader bool
+}
+
+func (w *headerWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if p := w.holder.get(); p != nil && status == http.StatusOK {
+		w.Header().Set("Cache-Control", p.Header())
+		if p.Scope == ScopePrivate {
+			w.Header().Add("Vary", "Authorization")
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *headerWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *headerWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
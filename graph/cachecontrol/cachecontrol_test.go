@@ -0,0 +1,180 @@
+package cachecontrol_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/natnael_wondwoesn/GGStarter/graph"
+	"github.com/natnael_wondwoesn/GGStarter/graph/cachecontrol"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/ratelimit"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.uber.org/zap"
+)
+
+// newServer returns the API's schema with the cache control extension
+// followed by extensions, in registration order.
+func newServer(db store.Store, extensions ...graphql.HandlerExtension) *handler.Server {
+	cfg := graph.Config{Resolvers: &graph.Resolver{Store: db, Events: pubsub.NewHub[*model.Todo]()}}
+	cfg.Directives.Auth = graph.Auth
+	cfg.Directives.HasRole = graph.HasRole
+	srv := handler.New(graph.NewExecutableSchema(cfg))
+	srv.AddTransport(transport.GET{})
+	srv.Use(&cachecontrol.Extension{})
+	for _, ext := range extensions {
+		srv.Use(ext)
+	}
+	return srv
+}
+
+func get(h http.Handler, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/query?query="+url.QueryEscape(query), nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// rejectAll is an OperationContextMutator refusing every operation.
+type rejectAll struct{}
+
+func (rejectAll) ExtensionName() string                   { return "RejectAll" }
+func (rejectAll) Validate(graphql.ExecutableSchema) error { return nil }
+func (rejectAll) MutateOperationContext(context.Context, *graphql.OperationContext) *gqlerror.Error {
+	return gqlerror.Errorf("rejected")
+}
+
+func TestMiddlewareSetsPolicyOfQuery(t *testing.T) {
+	h := cachecontrol.Middleware(newServer(store.NewMemory()))
+
+	rec := get(h, "{ todos { id } }")
+	if got := rec.Header().Get("Cache-Control"); got != "max-age=30, public" {
+		t.Errorf("Cache-Control = %q, want max-age=30, public", got)
+	}
+	rec = get(h, "{ __schema { queryType { name } } }")
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("introspection Cache-Control = %q, want no-store", got)
+	}
+}
+
+func TestMiddlewareSkipsRejectedOperations(t *testing.T) {
+	h := cachecontrol.Middleware(newServer(store.NewMemory(), rejectAll{}))
+
+	rec := get(h, "{ todos { id } }")
+	if !strings.Contains(rec.Body.String(), "rejected") {
+		t.Fatalf("body %s lacks the mutator error", rec.Body)
+	}
+	if got, ok := rec.Header()["Cache-Control"]; ok {
+		t.Errorf("rejected operation has Cache-Control %q", got)
+	}
+}
+
+func TestRateLimitedQueriesAreNotCached(t *testing.T) {
+	for _, withResponseCache := range []bool{false, true} {
+		name := "headers only"
+		if withResponseCache {
+			name = "response cache"
+		}
+		t.Run(name, func(t *testing.T) {
+			var extensions []graphql.HandlerExtension
+			if withResponseCache {
+				extensions = append(extensions, cachecontrol.NewResponseCache(10))
+			}
+			extensions = append(extensions, ratelimit.Extension{
+				Limiter: ratelimit.NewMemory(nil),
+				Policy:  ratelimit.Policy{IP: ratelimit.Rate{Requests: 1, Period: time.Minute}},
+				Logger:  zap.NewNop(),
+			})
+			h := ratelimit.Middleware("")(cachecontrol.Middleware(newServer(store.NewMemory(), extensions...)))
+
+			if rec := get(h, "{ todos { id } }"); rec.Code != http.StatusOK {
+				t.Fatalf("first request: %d %s", rec.Code, rec.Body)
+			}
+			rec := get(h, "{ todos { id } }")
+			if rec.Code != http.StatusTooManyRequests {
+				t.Fatalf("second request: status %d, want 429", rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), ratelimit.CodeRateLimited) {
+				t.Errorf("body %s lacks %s", rec.Body, ratelimit.CodeRateLimited)
+			}
+			if got, ok := rec.Header()["Cache-Control"]; ok {
+				t.Errorf("rate-limited response has Cache-Control %q", got)
+			}
+		})
+	}
+}
+
+func TestMutationsInvalidateCachedConnections(t *testing.T) {
+	db := store.NewMemory()
+	ada := &model.User{Name: "Ada", Roles: []model.Role{model.RoleUser}}
+	if err := db.CreateUser(context.Background(), ada); err != nil {
+		t.Fatal(err)
+	}
+
+	responses := cachecontrol.NewResponseCache(10)
+	cfg := graph.Config{Resolvers: &graph.Resolver{Store: db, Events: pubsub.NewHub[*model.Todo](), Responses: responses}}
+	cfg.Directives.Auth = graph.Auth
+	cfg.Directives.HasRole = graph.HasRole
+	srv := handler.New(graph.NewExecutableSchema(cfg))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(&cachecontrol.Extension{})
+	srv.Use(responses)
+	claims := &auth.Claims{}
+	claims.Subject = ada.ID
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
+	})
+
+	query := `{ user(id: "` + ada.ID + `") { todos { totalCount } } }`
+	countIs := func(want string) {
+		t.Helper()
+		rec := get(h, query)
+		if body := rec.Body.String(); !strings.Contains(body, `"totalCount":`+want) {
+			t.Fatalf("body %s, want totalCount %s", body, want)
+		}
+	}
+
+	countIs("0")
+	countIs("0") // served from the cache
+
+	mutate := func(mutation string) string {
+		t.Helper()
+		body := `{"query":` + strconv.Quote(mutation) + `}`
+		req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if strings.Contains(rec.Body.String(), `"errors"`) {
+			t.Fatalf("%s: %s", mutation, rec.Body)
+		}
+		return rec.Body.String()
+	}
+
+	created := mutate(`mutation { createTodo(input: {text: "write tests", userId: "` + ada.ID + `"}) { id } }`)
+	countIs("1")
+
+	var resp struct {
+		Data struct {
+			CreateTodo struct{ ID string }
+		}
+	}
+	if err := json.Unmarshal([]byte(created), &resp); err != nil {
+		t.Fatal(err)
+	}
+	mutate(`mutation { deleteTodo(id: "` + resp.Data.CreateTodo.ID + `") { id } }`)
+	countIs("0")
+}
@@ -0,0 +1,180 @@
+package cachecontrol
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+)
+
+// Invalidator drops cached responses containing any of the given types.
+// Mutations call it after changing data.
+type Invalidator interface {
+	Invalidate(ctx context.Context, types ...string)
+}
+
+// ResponseCache is a gqlgen handler extension that serves cacheable queries
+// from memory for their policy's maxAge. Responses are keyed by query,
+// operation name and variables, plus the user ID for PRIVATE policies;
+// private queries from anonymous clients are not cached. Register it after
+// Extension, which computes the policy.
+type ResponseCache struct {
+	size int
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+	byType  map[string]map[string]bool
+}
+
+type entry struct {
+	key     string
+	data    []byte
+	expires time.Time
+	types   []string
+}
+
+var (
+	_ graphql.HandlerExtension    = (*ResponseCache)(nil)
+	_ graphql.ResponseInterceptor = (*ResponseCache)(nil)
+	_ Invalidator                 = (*ResponseCache)(nil)
+)
+
+// NewResponseCache returns a cache holding at most size responses.
+func NewResponseCache(size int) *ResponseCache {
+	return &ResponseCache{
+		size:    size,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		byType:  make(map[string]map[string]bool),
+	}
+}
+
+func (c *ResponseCache) ExtensionName() string {
+	return "ResponseCache"
+}
+
+func (c *ResponseCache) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse serves and stores executed operations only: errors
+// dispatched for a rejected operation, such as a rate-limited one, must
+// reach the client rather than be answered from the cache.
+func (c *ResponseCache) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	policy := PolicyFor(ctx)
+	if policy == nil || !policy.Cacheable() || !Executed(ctx) {
+		return next(ctx)
+	}
+	key, ok := responseKey(ctx, *policy)
+	if !ok {
+		return next(ctx)
+	}
+
+	if data, ok := c.get(key); ok {
+		var resp graphql.Response
+		if err := json.Unmarshal(data, &resp); err == nil {
+			return &resp
+		}
+	}
+
+	resp := next(ctx)
+	if resp == nil || len(resp.Errors) > 0 {
+		return resp
+	}
+	if data, err := json.Marshal(resp); err == nil {
+		c.add(key, data, time.Duration(policy.MaxAge)*time.Second, policy.Types)
+	}
+	return resp
+}
+
+// Invalidate drops every cached response containing one of types.
+func (c *ResponseCache) Invalidate(_ context.Context, types ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range types {
+		for key := range c.byType[t] {
+			if el, ok := c.entries[key]; ok {
+				c.remove(el)
+			}
+		}
+	}
+}
+
+func (c *ResponseCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !c.now().Before(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return e.data, true
+}
+
+func (c *ResponseCache) add(key string, data []byte, ttl time.Duration, types []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	e := &entry{key: key, data: data, expires: c.now().Add(ttl), types: types}
+	c.entries[key] = c.order.PushFront(e)
+	for _, t := range types {
+		if c.byType[t] == nil {
+			c.byType[t] = make(map[string]bool)
+		}
+		c.byType[t][key] = true
+	}
+	for c.size > 0 && c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *ResponseCache) remove(el *list.Element) {
+	e := c.order.Remove(el).(*entry)
+	delete(c.entries, e.key)
+	for _, t := range e.types {
+		delete(c.byType[t], e.key)
+		if len(c.byType[t]) == 0 {
+			delete(c.byType, t)
+		}
+	}
+}
+
+func responseKey(ctx context.Context, policy Policy) (string, bool) {
+	opCtx := graphql.GetOperationContext(ctx)
+	vars, err := json.Marshal(opCtx.Variables)
+	if err != nil {
+		return "", false
+	}
+
+	h := sha256.New()
+	h.Write([]byte(opCtx.RawQuery))
+	h.Write([]byte{0})
+	h.Write([]byte(opCtx.OperationName))
+	h.Write([]byte{0})
+	h.Write(vars)
+	if policy.Scope == ScopePrivate {
+		claims := auth.ForContext(ctx)
+		if claims == nil {
+			return "", false
+		}
+		h.Write([]byte{0})
+		h.Write([]byte(claims.UserID()))
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
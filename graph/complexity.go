@@ -0,0 +1,17 @@
+package graph
+
+// SetComplexity installs complexity functions for fields whose cost grows
+// with their arguments or their results. Paginated fields cost their child
+// selection once per requested item, so todos → user → todos multiplies as
+// it nests. Unpaginated lists are costed as if they held a full page.
+func SetComplexity(c *ComplexityRoot) {
+	c.Query.Todos = func(childComplexity int) int {
+		return 1 + childComplexity*maxPageSize
+	}
+	c.Query.Users = func(childComplexity int) int {
+		return 1 + childComplexity*maxPageSize
+	}
+	c.User.Todos = func(childComplexity int, first *int32, after *string) int {
+		return 1 + childComplexity*pageSize(first)
+	}
+}
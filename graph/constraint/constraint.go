@@ -0,0 +1,279 @@
+// Package constraint enforces the @constraint directive on arguments and
+// input fields. Every argument of an operation is checked before any
+// resolver runs, and all violations are reported together as one
+// validation error listing each field in extensions.fields.
+package constraint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/natnael_wondwoesn/GGStarter/graph/scalars"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const directiveName = "constraint"
+
+// formats are the values accepted by the format argument, checked with the
+// parser of the matching custom scalar.
+var formats = map[string]func(any) error{
+	"email":     func(v any) error { _, err := scalars.UnmarshalEmail(v); return err },
+	"url":       func(v any) error { _, err := scalars.UnmarshalURL(v); return err },
+	"uuid":      func(v any) error { _, err := scalars.UnmarshalUUID(v); return err },
+	"date-time": func(v any) error { _, err := scalars.UnmarshalDateTime(v); return err },
+}
+
+// rule is a parsed @constraint.
+type rule struct {
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	min, max             *float64
+	format               string
+}
+
+// Extension is a gqlgen handler extension enforcing @constraint.
+type Extension struct {
+	schema *ast.Schema
+	rules  map[*ast.Directive]*rule
+}
+
+var (
+	_ graphql.HandlerExtension        = (*Extension)(nil)
+	_ graphql.OperationContextMutator = (*Extension)(nil)
+)
+
+func (e *Extension) ExtensionName() string {
+	return "Constraint"
+}
+
+// Validate parses every @constraint in the schema up front, so that an
+// invalid pattern or format fails at startup rather than per request.
+func (e *Extension) Validate(schema graphql.ExecutableSchema) error {
+	e.schema = schema.Schema()
+	e.rules = make(map[*ast.Directive]*rule)
+
+	add := func(where string, dirs ast.DirectiveList) error {
+		d := dirs.ForName(directiveName)
+		if d == nil {
+			return nil
+		}
+		r, err := parseRule(d)
+		if err != nil {
+			return fmt.Errorf("@constraint on %s: %w", where, err)
+		}
+		e.rules[d] = r
+		return nil
+	}
+	for _, def := range e.schema.Types {
+		for _, f := range def.Fields {
+			switch def.Kind {
+			case ast.InputObject:
+				if err := add(def.Name+"."+f.Name, f.Directives); err != nil {
+					return err
+				}
+			case ast.Object, ast.Interface:
+				for _, arg := range f.Arguments {
+					if err := add(def.Name+"."+f.Name+"("+arg.Name+")", arg.Directives); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (e *Extension) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	c := checker{ext: e, vars: opCtx.Variables}
+	c.selections(opCtx.Operation.SelectionSet)
+	if len(c.violations) == 0 {
+		return nil
+	}
+
+	appErr := apperr.Validation(c.violations...)
+	return &gqlerror.Error{
+		Err:     appErr,
+		Message: appErr.Message,
+		Extensions: map[string]any{
+			"code":   string(apperr.CodeValidation),
+			"fields": appErr.Fields,
+		},
+	}
+}
+
+func parseRule(d *ast.Directive) (*rule, error) {
+	r := &rule{}
+	for _, arg := range d.Arguments {
+		v, err := arg.Value.Value(nil)
+		if err != nil {
+			return nil, err
+		}
+		switch arg.Name {
+		case "minLength", "maxLength":
+			n, ok := v.(int64)
+			if !ok || n < 0 {
+				return nil, fmt.Errorf("%s must be a non-negative integer", arg.Name)
+			}
+			i := int(n)
+			if arg.Name == "minLength" {
+				r.minLength = &i
+			} else {
+				r.maxLength = &i
+			}
+		case "min", "max":
+			f, ok := number(v)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a number", arg.Name)
+			}
+			if arg.Name == "min" {
+				r.min = &f
+			} else {
+				r.max = &f
+			}
+		case "pattern":
+			s, _ := v.(string)
+			re, err := regexp.Compile(s)
+			if err != nil {
+				return nil, fmt.Errorf("pattern: %w", err)
+			}
+			r.pattern = re
+		case "format":
+			s, _ := v.(string)
+			if _, ok := formats[s]; !ok {
+				return nil, fmt.Errorf("unknown format %q", s)
+			}
+			r.format = s
+		}
+	}
+	return r, nil
+}
+
+type checker struct {
+	ext        *Extension
+	vars       map[string]any
+	violations []apperr.FieldError
+}
+
+// selections checks the arguments of every field in set. Paths are
+// relative to the field's arguments, such as "input.text", as documented
+// on apperr.FieldError.
+func (c *checker) selections(set ast.SelectionSet) {
+	for _, sel := range set {
+		switch s := sel.(type) {
+		case *ast.Field:
+			if s.Definition == nil {
+				continue
+			}
+			args := s.ArgumentMap(c.vars)
+			for _, def := range s.Definition.Arguments {
+				c.value(def.Name, def.Type, def.Directives, args[def.Name])
+			}
+			c.selections(s.SelectionSet)
+		case *ast.InlineFragment:
+			c.selections(s.SelectionSet)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				c.selections(s.Definition.SelectionSet)
+			}
+		}
+	}
+}
+
+// value checks v against the constraint in dirs and, for input objects,
+// the constraints of each field. A constraint on a list applies to its
+// elements.
+func (c *checker) value(path string, typ *ast.Type, dirs ast.DirectiveList, v any) {
+	if v == nil {
+		return
+	}
+	if typ.Elem != nil {
+		if list, ok := v.([]any); ok {
+			for i, elem := range list {
+				c.value(path+"."+strconv.Itoa(i), typ.Elem, dirs, elem)
+			}
+			return
+		}
+		// A single value is coerced to a list of one.
+		c.value(path, typ.Elem, dirs, v)
+		return
+	}
+
+	if d := dirs.ForName(directiveName); d != nil {
+		if r := c.ext.rules[d]; r != nil {
+			for _, msg := range r.check(v) {
+				c.violations = append(c.violations, apperr.FieldError{Path: path, Message: msg})
+			}
+		}
+	}
+
+	def := c.ext.schema.Types[typ.Name()]
+	if obj, ok := v.(map[string]any); ok && def != nil && def.Kind == ast.InputObject {
+		for _, f := range def.Fields {
+			c.value(path+"."+f.Name, f.Type, f.Directives, obj[f.Name])
+		}
+	}
+}
+
+// check returns a message for each part of r that v violates.
+func (r *rule) check(v any) []string {
+	var msgs []string
+	if s, ok := v.(string); ok {
+		n := utf8.RuneCountInString(s)
+		if r.minLength != nil && n < *r.minLength {
+			msgs = append(msgs, fmt.Sprintf("must be at least %s; got %d", characters(*r.minLength), n))
+		}
+		if r.maxLength != nil && n > *r.maxLength {
+			msgs = append(msgs, fmt.Sprintf("must be at most %s; got %d", characters(*r.maxLength), n))
+		}
+		if r.pattern != nil && !r.pattern.MatchString(s) {
+			msgs = append(msgs, fmt.Sprintf("must match %s", r.pattern))
+		}
+		if r.format != "" && formats[r.format](s) != nil {
+			msgs = append(msgs, "must be a valid "+r.format)
+		}
+	}
+	if f, ok := number(v); ok {
+		if r.min != nil && f < *r.min {
+			msgs = append(msgs, "must be at least "+formatNumber(*r.min)+"; got "+formatNumber(f))
+		}
+		if r.max != nil && f > *r.max {
+			msgs = append(msgs, "must be at most "+formatNumber(*r.max)+"; got "+formatNumber(f))
+		}
+	}
+	return msgs
+}
+
+// number converts the numeric forms of literals and decoded variables.
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func characters(n int) string {
+	if n == 1 {
+		return "1 character"
+	}
+	return strconv.Itoa(n) + " characters"
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,193 @@
+package constraint_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor"
+	"github.com/natnael_wondwoesn/GGStarter/graph/constraint"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const sdl = `
+directive @constraint(
+  minLength: Int
+  maxLength: Int
+  pattern: String
+  min: Float
+  max: Float
+  format: String
+) on ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+
+input Tag {
+  name: String! @constraint(minLength: 2)
+}
+
+input NewPost {
+  title: String! @constraint(maxLength: 5)
+  author: String @constraint(format: "email")
+  tags: [Tag!]
+}
+
+type Post {
+  id: ID!
+  related(first: Int @constraint(min: 0, max: 10)): [Post!]!
+}
+
+type Query {
+  post(slug: String! @constraint(pattern: "^[a-z]+$")): Post
+}
+
+type Mutation {
+  createPost(input: NewPost!): Post!
+  label(names: [String!]! @constraint(minLength: 1)): Boolean!
+}
+`
+
+// schema is an executable schema with no resolvers: the tests only run the
+// operation context mutators.
+type schema struct{ s *ast.Schema }
+
+func (s schema) Schema() *ast.Schema { return s.s }
+
+func (schema) Complexity(string, string, int, map[string]any) (int, bool) {
+	return 0, false
+}
+
+func (schema) Exec(context.Context) graphql.ResponseHandler {
+	return nil
+}
+
+func newExecutor(t *testing.T, input string) *executor.Executor {
+	t.Helper()
+	s, err := gqlparser.LoadSchema(&ast.Source{Name: "test.graphqls", Input: input})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := executor.New(schema{s})
+	exec.Use(&constraint.Extension{})
+	return exec
+}
+
+func TestViolationPaths(t *testing.T) {
+	exec := newExecutor(t, sdl)
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		want      []apperr.FieldError
+	}{
+		{
+			name:  "valid",
+			query: `{ post(slug: "hello") { id related(first: 3) { id } } }`,
+		},
+		{
+			name:  "literal argument",
+			query: `{ post(slug: "Hello") { id } }`,
+			want:  []apperr.FieldError{{Path: "slug", Message: "must match ^[a-z]+$"}},
+		},
+		{
+			name:      "variable argument",
+			query:     `query ($s: String!) { post(slug: $s) { id } }`,
+			variables: map[string]any{"s": "Hello"},
+			want:      []apperr.FieldError{{Path: "slug", Message: "must match ^[a-z]+$"}},
+		},
+		{
+			name:  "alias",
+			query: `{ a: post(slug: "ok") { id } b: post(slug: "NO") { id } }`,
+			want:  []apperr.FieldError{{Path: "slug", Message: "must match ^[a-z]+$"}},
+		},
+		{
+			name:  "nested selection",
+			query: `{ post(slug: "ok") { related(first: 11) { id } } }`,
+			want:  []apperr.FieldError{{Path: "first", Message: "must be at most 10; got 11"}},
+		},
+		{
+			name:  "input object literal",
+			query: `mutation { createPost(input: {title: "too long", author: "nobody"}) { id } }`,
+			want: []apperr.FieldError{
+				{Path: "input.title", Message: "must be at most 5 characters; got 8"},
+				{Path: "input.author", Message: "must be a valid email"},
+			},
+		},
+		{
+			name:      "input object variable",
+			query:     `mutation ($in: NewPost!) { createPost(input: $in) { id } }`,
+			variables: map[string]any{"in": map[string]any{"title": "too long"}},
+			want:      []apperr.FieldError{{Path: "input.title", Message: "must be at most 5 characters; got 8"}},
+		},
+		{
+			name:      "variable inside literal",
+			query:     `mutation ($t: String!) { createPost(input: {title: $t}) { id } }`,
+			variables: map[string]any{"t": "too long"},
+			want:      []apperr.FieldError{{Path: "input.title", Message: "must be at most 5 characters; got 8"}},
+		},
+		{
+			name:  "list of input objects",
+			query: `mutation { createPost(input: {title: "t", tags: [{name: "go"}, {name: "x"}]}) { id } }`,
+			want:  []apperr.FieldError{{Path: "input.tags.1.name", Message: "must be at least 2 characters; got 1"}},
+		},
+		{
+			name:  "list elements",
+			query: `mutation { label(names: ["a", "", "b", ""]) }`,
+			want: []apperr.FieldError{
+				{Path: "names.1", Message: "must be at least 1 character; got 0"},
+				{Path: "names.3", Message: "must be at least 1 character; got 0"},
+			},
+		},
+		{
+			name:  "single value coerced to a list",
+			query: `mutation { label(names: "") }`,
+			want:  []apperr.FieldError{{Path: "names", Message: "must be at least 1 character; got 0"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := graphql.StartOperationTrace(context.Background())
+			_, errs := exec.CreateOperationContext(ctx, &graphql.RawParams{Query: tt.query, Variables: tt.variables})
+			if len(tt.want) == 0 {
+				if len(errs) != 0 {
+					t.Fatalf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("got errors %v, want one validation error", errs)
+			}
+			if code := errs[0].Extensions["code"]; code != string(apperr.CodeValidation) {
+				t.Errorf("code = %v, want %s", code, apperr.CodeValidation)
+			}
+			if got := errs[0].Extensions["fields"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name, directive, want string
+	}{
+		{"pattern", `@constraint(pattern: "[a-")`, "@constraint on Query.post(slug): pattern:"},
+		{"format", `@constraint(format: "phone")`, `@constraint on Query.post(slug): unknown format "phone"`},
+		{"length", `@constraint(minLength: -1)`, "minLength must be a non-negative integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := strings.Replace(sdl, `@constraint(pattern: "^[a-z]+$")`, tt.directive, 1)
+			s, err := gqlparser.LoadSchema(&ast.Source{Name: "test.graphqls", Input: input})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = (&constraint.Extension{}).Validate(schema{s})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Package dataloader batches and caches the lookups made by field resolvers
+// so that resolving a list does not issue one store query per element.
+package dataloader
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+)
+
+type ctxKey struct{}
+
+// Loaders holds the per-request loaders.
+type Loaders struct {
+	UserByID          *Loader[string, *model.User]
+	TodosByUser       *Loader[string, []*model.Todo]
+	AttachmentsByTodo *Loader[string, []*model.Attachment]
+}
+
+// NewLoaders returns a fresh set of loaders reading from s.
+func NewLoaders(s store.Store, opts ...Option) *Loaders {
+	return &Loaders{
+		UserByID:          NewLoader(usersByID(s), opts...),
+		TodosByUser:       NewLoader(todosByUser(s), opts...),
+		AttachmentsByTodo: NewLoader(attachmentsByTodo(s), opts...),
+	}
+}
+
+// Extension is a gqlgen handler extension installing a fresh set of loaders
+// for every response: once per query or mutation and once per subscription
+// event. Cached values therefore never outlive the response they were read
+// for, however long a WebSocket connection stays open. An HTTP middleware
+// would install loaders once per request, which for a subscription is the
+// whole connection, so its events would be served stale cached users.
+type Extension struct {
+	Store   store.Store
+	Options []Option
+}
+
+var (
+	_ graphql.HandlerExtension    = Extension{}
+	_ graphql.ResponseInterceptor = Extension{}
+)
+
+func (Extension) ExtensionName() string {
+	return "DataLoader"
+}
+
+func (Extension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	return next(WithLoaders(ctx, NewLoaders(e.Store, e.Options...)))
+}
+
+// WithLoaders returns a copy of ctx carrying l.
+func WithLoaders(ctx context.Context, l *Loaders) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// For returns the loaders installed by Extension, or nil.
+func For(ctx context.Context) *Loaders {
+	l, _ := ctx.Value(ctxKey{}).(*Loaders)
+	return l
+}
+
+func usersByID(s store.UserStore) BatchFunc[string, *model.User] {
+	return func(ctx context.Context, ids []string) ([]*model.User, []error) {
+		users, err := s.UsersByIDs(ctx, ids)
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		byID := make(map[string]*model.User, len(users))
+		for _, u := range users {
+			byID[u.ID] = u
+		}
+
+		result := make([]*model.User, len(ids))
+		errs := make([]error, len(ids))
+		for i, id := range ids {
+			if u, ok := byID[id]; ok {
+				result[i] = u
+			} else {
+				errs[i] = store.ErrNotFound
+			}
+		}
+		return result, errs
+	}
+}
+
+func todosByUser(s store.TodoStore) BatchFunc[string, []*model.Todo] {
+	return func(ctx context.Context, userIDs []string) ([][]*model.Todo, []error) {
+		todos, err := s.TodosByUserIDs(ctx, userIDs)
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		byUser := make(map[string][]*model.Todo, len(userIDs))
+		for _, t := range todos {
+			byUser[t.UserID] = append(byUser[t.UserID], t)
+		}
+
+		result := make([][]*model.Todo, len(userIDs))
+		for i, id := range userIDs {
+			result[i] = byUser[id]
+		}
+		return result, nil
+	}
+}
+
+func attachmentsByTodo(s store.AttachmentStore) BatchFunc[string, []*model.Attachment] {
+	return func(ctx context.Context, todoIDs []string) ([][]*model.Attachment, []error) {
+		attachments, err := s.AttachmentsByTodoIDs(ctx, todoIDs)
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		byTodo := make(map[string][]*model.Attachment, len(todoIDs))
+		for _, a := range attachments {
+			byTodo[a.TodoID] = append(byTodo[a.TodoID], a)
+		}
+
+		result := make([][]*model.Attachment, len(todoIDs))
+		for i, id := range todoIDs {
+			result[i] = byTodo[id]
+		}
+		return result, nil
+	}
+}
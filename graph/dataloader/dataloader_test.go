@@ -0,0 +1,178 @@
+package dataloader_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/natnael_wondwoesn/GGStarter/graph"
+	"github.com/natnael_wondwoesn/GGStarter/graph/dataloader"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+)
+
+// countingStore records the IDs of every UsersByIDs call and counts
+// unbatched User calls.
+type countingStore struct {
+	store.Store
+
+	mu     sync.Mutex
+	calls  [][]string
+	single int
+}
+
+func (s *countingStore) UsersByIDs(ctx context.Context, ids []string) ([]*model.User, error) {
+	s.mu.Lock()
+	s.calls = append(s.calls, slices.Clone(ids))
+	s.mu.Unlock()
+	return s.Store.UsersByIDs(ctx, ids)
+}
+
+func (s *countingStore) User(ctx context.Context, id string) (*model.User, error) {
+	s.mu.Lock()
+	s.single++
+	s.mu.Unlock()
+	return s.Store.User(ctx, id)
+}
+
+// seed stores n todos spread over three users and returns the user IDs.
+func seed(t *testing.T, mem *store.Memory, n int) []string {
+	t.Helper()
+	ctx := context.Background()
+	var userIDs []string
+	for i := range 3 {
+		u := &model.User{Name: fmt.Sprintf("user %d", i)}
+		if err := mem.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		userIDs = append(userIDs, u.ID)
+	}
+	for i := range n {
+		todo := &model.Todo{Text: fmt.Sprintf("todo %d", i), UserID: userIDs[i%len(userIDs)]}
+		if err := mem.CreateTodo(ctx, todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return userIDs
+}
+
+// checkOneBatch fails unless s saw a single UsersByIDs call for exactly
+// userIDs and no unbatched lookups.
+func checkOneBatch(t *testing.T, s *countingStore, userIDs []string, n int) {
+	t.Helper()
+	if len(s.calls) != 1 || s.single != 0 {
+		t.Fatalf("%d UsersByIDs and %d User calls for %d todos, want one batch", len(s.calls), s.single, n)
+	}
+	got := slices.Sorted(slices.Values(s.calls[0]))
+	want := slices.Sorted(slices.Values(userIDs))
+	if !slices.Equal(got, want) {
+		t.Errorf("UsersByIDs(%v), want the deduplicated owners %v", got, want)
+	}
+}
+
+func TestUserByIDBatchesTodoOwners(t *testing.T) {
+	ctx := context.Background()
+	mem := store.NewMemory()
+	const n = 30
+	userIDs := seed(t, mem, n)
+	todos, err := mem.Todos(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &countingStore{Store: mem}
+	loaders := dataloader.NewLoaders(s, dataloader.WithWait(20*time.Millisecond))
+
+	// Resolve Todo.user for every todo concurrently, as the executor does.
+	var wg sync.WaitGroup
+	for _, todo := range todos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := loaders.UserByID.Load(ctx, todo.UserID)
+			if err != nil {
+				t.Errorf("load user of %s: %v", todo.ID, err)
+				return
+			}
+			if u.ID != todo.UserID {
+				t.Errorf("todo %s: got user %s, want %s", todo.ID, u.ID, todo.UserID)
+			}
+		}()
+	}
+	wg.Wait()
+
+	checkOneBatch(t, s, userIDs, n)
+}
+
+func TestTodoOwnersLoadInOneBatchThroughExecutor(t *testing.T) {
+	mem := store.NewMemory()
+	const n = 30
+	userIDs := seed(t, mem, n)
+	s := &countingStore{Store: mem}
+
+	cfg := graph.Config{Resolvers: &graph.Resolver{Store: s, Events: pubsub.NewHub[*model.Todo]()}}
+	cfg.Directives.Auth = graph.Auth
+	cfg.Directives.HasRole = graph.HasRole
+	srv := handler.New(graph.NewExecutableSchema(cfg))
+	srv.AddTransport(transport.POST{})
+	// An explicit window keeps the batch whole on a loaded machine.
+	srv.Use(dataloader.Extension{Store: s, Options: []dataloader.Option{dataloader.WithWait(20 * time.Millisecond)}})
+
+	req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader([]byte(`{"query":"{ todos { user { id } } }"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var resp struct {
+		Data struct {
+			Todos []struct {
+				User struct{ ID string }
+			}
+		}
+		Errors []any
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Errors) != 0 {
+		t.Fatalf("query failed: %s", rec.Body)
+	}
+	if len(resp.Data.Todos) != n {
+		t.Fatalf("got %d todos, want %d", len(resp.Data.Todos), n)
+	}
+	for _, todo := range resp.Data.Todos {
+		if !slices.Contains(userIDs, todo.User.ID) {
+			t.Errorf("todo owned by unknown user %q", todo.User.ID)
+		}
+	}
+	checkOneBatch(t, s, userIDs, n)
+}
+
+func TestExtensionInstallsLoadersPerResponse(t *testing.T) {
+	ext := dataloader.Extension{Store: store.NewMemory()}
+
+	var seen []*dataloader.Loaders
+	next := func(ctx context.Context) *graphql.Response {
+		seen = append(seen, dataloader.For(ctx))
+		return &graphql.Response{}
+	}
+	// A subscription passes each event through InterceptResponse.
+	for range 2 {
+		ext.InterceptResponse(context.Background(), next)
+	}
+
+	if len(seen) != 2 || seen[0] == nil || seen[1] == nil {
+		t.Fatalf("loaders not installed: %v", seen)
+	}
+	if seen[0] == seen[1] {
+		t.Error("consecutive responses share loaders")
+	}
+}
@@ -0,0 +1,190 @@
+package dataloader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchFunc fetches values for keys in one round trip. It must return one
+// value per key, in key order. errs may be nil, hold a single error that
+// applies to every key, or hold one error per key.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (values []V, errs []error)
+
+// Loader coalesces individual Load calls made within a short window into a
+// single BatchFunc call. Keys are deduplicated and results are cached for the
+// lifetime of the Loader, which is meant to be one request.
+type Loader[K comparable, V any] struct {
+	fetch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu    sync.Mutex
+	cache map[K]*thunk[V]
+	batch *batch[K, V]
+}
+
+type thunk[V any] struct {
+	value V
+	err   error
+	done  chan struct{}
+}
+
+type batch[K comparable, V any] struct {
+	keys       []K
+	thunks     []*thunk[V]
+	dispatched bool
+}
+
+// Option configures a Loader.
+type Option func(*options)
+
+type options struct {
+	wait     time.Duration
+	maxBatch int
+}
+
+// WithWait sets how long the loader collects keys before dispatching a batch.
+func WithWait(d time.Duration) Option {
+	return func(o *options) { o.wait = d }
+}
+
+// WithMaxBatch dispatches a batch early once it holds n keys. Zero means no
+// limit.
+func WithMaxBatch(n int) Option {
+	return func(o *options) { o.maxBatch = n }
+}
+
+// NewLoader returns a Loader that fetches through fn.
+func NewLoader[K comparable, V any](fn BatchFunc[K, V], opts ...Option) *Loader[K, V] {
+	o := options{wait: 2 * time.Millisecond}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Loader[K, V]{
+		fetch:    fn,
+		wait:     o.wait,
+		maxBatch: o.maxBatch,
+		cache:    make(map[K]*thunk[V]),
+	}
+}
+
+// Load returns the value for key, waiting for the batch it joins to finish.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	t := l.enqueue(ctx, key)
+	select {
+	case <-t.done:
+		return t.value, t.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// LoadAll returns values for keys in order. All keys join the same batch.
+func (l *Loader[K, V]) LoadAll(ctx context.Context, keys []K) ([]V, []error) {
+	thunks := make([]*thunk[V], len(keys))
+	for i, key := range keys {
+		thunks[i] = l.enqueue(ctx, key)
+	}
+
+	values := make([]V, len(keys))
+	errs := make([]error, len(keys))
+	for i, t := range thunks {
+		select {
+		case <-t.done:
+			values[i], errs[i] = t.value, t.err
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+		}
+	}
+	return values, errs
+}
+
+// Prime stores value for key unless the key is already cached.
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.cache[key]; ok {
+		return
+	}
+	t := &thunk[V]{value: value, done: make(chan struct{})}
+	close(t.done)
+	l.cache[key] = t
+}
+
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K) *thunk[V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if t, ok := l.cache[key]; ok {
+		return t
+	}
+
+	t := &thunk[V]{done: make(chan struct{})}
+	l.cache[key] = t
+
+	b := l.batch
+	if b == nil {
+		b = &batch[K, V]{}
+		l.batch = b
+		go l.dispatchAfterWait(ctx, b)
+	}
+	b.keys = append(b.keys, key)
+	b.thunks = append(b.thunks, t)
+
+	if l.maxBatch > 0 && len(b.keys) >= l.maxBatch {
+		b.dispatched = true
+		l.batch = nil
+		go l.run(ctx, b)
+	}
+	return t
+}
+
+func (l *Loader[K, V]) dispatchAfterWait(ctx context.Context, b *batch[K, V]) {
+	time.Sleep(l.wait)
+
+	l.mu.Lock()
+	if b.dispatched {
+		l.mu.Unlock()
+		return
+	}
+	b.dispatched = true
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	l.run(ctx, b)
+}
+
+func (l *Loader[K, V]) run(ctx context.Context, b *batch[K, V]) {
+	values, errs := l.fetchBatch(ctx, b.keys)
+
+	for i, t := range b.thunks {
+		switch {
+		case len(errs) == 1:
+			t.err = errs[0]
+		case i < len(errs):
+			t.err = errs[i]
+		}
+		if t.err == nil && i < len(values) {
+			t.value = values[i]
+		}
+		close(t.done)
+	}
+}
+
+// fetchBatch calls fetch, turning a panic into an error for every key. The
+// batch runs on its own goroutine, where a panic would crash the process
+// and leave every waiting Load blocked.
+func (l *Loader[K, V]) fetchBatch(ctx context.Context, keys []K) (values []V, errs []error) {
+	defer func() {
+		if r := recover(); r != nil {
+			values, errs = nil, []error{fmt.Errorf("dataloader: batch function panicked: %v", r)}
+		}
+	}()
+	return l.fetch(context.WithoutCancel(ctx), keys)
+}
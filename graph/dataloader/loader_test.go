@@ -0,0 +1,58 @@
+package dataloader_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/natnael_wondwoesn/GGStarter/graph/dataloader"
+)
+
+func TestPanickingBatchFailsEveryKey(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	l := dataloader.NewLoader(func(ctx context.Context, keys []string) ([]string, []error) {
+		calls++
+		if calls == 1 {
+			panic("store exploded")
+		}
+		return keys, nil
+	}, dataloader.WithWait(10*time.Millisecond))
+
+	keys := []string{"a", "b", "c"}
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = l.Load(ctx, key)
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Load still waiting after the batch panicked")
+	}
+
+	if calls != 1 {
+		t.Fatalf("batch function called %d times, want 1", calls)
+	}
+	for i, err := range errs {
+		if err == nil || !strings.Contains(err.Error(), "store exploded") {
+			t.Errorf("key %s: err = %v, want the panic", keys[i], err)
+		}
+	}
+
+	// The loader keeps working for keys outside the failed batch.
+	if v, err := l.Load(ctx, "d"); err != nil || v != "d" {
+		t.Errorf("Load after a panic = %q, %v", v, err)
+	}
+}
@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+)
+
+var errUnauthenticated = apperr.Unauthenticated("authentication required")
+
+// Auth implements @auth.
+func Auth(ctx context.Context, obj any, next graphql.Resolver) (any, error) {
+	if auth.ForContext(ctx) == nil {
+		return nil, errUnauthenticated
+	}
+	return next(ctx)
+}
+
+// HasRole implements @hasRole.
+func HasRole(ctx context.Context, obj any, next graphql.Resolver, role model.Role) (any, error) {
+	claims := auth.ForContext(ctx)
+	if claims == nil {
+		return nil, errUnauthenticated
+	}
+	if !hasRole(claims, role) {
+		return nil, apperr.Forbidden("requires role " + string(role))
+	}
+	return next(ctx)
+}
+
+func hasRole(claims *auth.Claims, role model.Role) bool {
+	return slices.Contains(claims.Roles, string(model.RoleAdmin)) ||
+		slices.Contains(claims.Roles, string(role))
+}
+
+// requireOwner allows the owner of userID, or an admin, to continue.
+func requireOwner(ctx context.Context, userID string) error {
+	claims := auth.ForContext(ctx)
+	if claims == nil {
+		return errUnauthenticated
+	}
+	if claims.UserID() != userID && !hasRole(claims, model.RoleAdmin) {
+		return apperr.Forbidden("you may only modify your own todos")
+	}
+	return nil
+}
+
+// ownedTodo loads todo id and checks that the caller may modify it.
+func (r *Resolver) ownedTodo(ctx context.Context, id string) (*model.Todo, error) {
+	todo, err := r.Store.Todo(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.NotFound("todo", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := requireOwner(ctx, todo.UserID); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
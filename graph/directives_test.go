@@ -0,0 +1,328 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/storage"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+	"go.uber.org/zap"
+)
+
+var (
+	alice = &auth.Claims{Roles: []string{string(model.RoleUser)}}
+	bob   = &auth.Claims{Roles: []string{string(model.RoleUser)}}
+	admin = &auth.Claims{Roles: []string{string(model.RoleAdmin)}}
+)
+
+func init() {
+	alice.Subject, bob.Subject, admin.Subject = "alice", "bob", "admin"
+}
+
+// authFixture serves the schema with its directives and presenter, backed
+// by a store holding alice and bob and a todo owned by alice.
+type authFixture struct {
+	srv      *handler.Server
+	resolver *Resolver
+	todoID   string
+}
+
+func newAuthFixture(t *testing.T) *authFixture {
+	t.Helper()
+	db := store.NewMemory()
+	ctx := context.Background()
+	for _, id := range []string{"alice", "bob"} {
+		if err := db.CreateUser(ctx, &model.User{ID: id, Name: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	todo := &model.Todo{Text: "alice's todo", UserID: "alice"}
+	if err := db.CreateTodo(ctx, todo); err != nil {
+		t.Fatal(err)
+	}
+	files, err := storage.NewLocal(storage.LocalOptions{Dir: t.TempDir(), URLPrefix: "/files"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := &Resolver{
+		Store:            db,
+		Events:           pubsub.NewHub[*model.Todo](),
+		Files:            files,
+		AllowedFileTypes: []string{"text/plain"},
+	}
+	cfg := Config{Resolvers: resolver}
+	cfg.Directives.Auth = Auth
+	cfg.Directives.HasRole = HasRole
+	srv := handler.New(NewExecutableSchema(cfg))
+	srv.SetErrorPresenter(apperr.Presenter(zap.NewNop(), false))
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+	return &authFixture{srv: srv, resolver: resolver, todoID: todo.ID}
+}
+
+// exec runs req as claims, or anonymously when claims is nil, returning
+// the extensions.code of every error.
+func (f *authFixture) exec(t *testing.T, claims *auth.Claims, req *http.Request) []string {
+	t.Helper()
+	_, codes := f.serve(t, claims, req)
+	return codes
+}
+
+// query runs query as claims, decoding its data into data, and returns the
+// extensions.code of every error.
+func (f *authFixture) query(t *testing.T, claims *auth.Claims, query string, variables map[string]any, data any) []string {
+	t.Helper()
+	raw, codes := f.serve(t, claims, postJSON(t, query, variables))
+	if len(codes) == 0 {
+		if err := json.Unmarshal(raw, data); err != nil {
+			t.Fatalf("decode data %s: %v", raw, err)
+		}
+	}
+	return codes
+}
+
+func (f *authFixture) serve(t *testing.T, claims *auth.Claims, req *http.Request) (json.RawMessage, []string) {
+	t.Helper()
+	if claims != nil {
+		req = req.WithContext(auth.WithClaims(req.Context(), claims))
+	}
+	rec := httptest.NewRecorder()
+	f.srv.ServeHTTP(rec, req)
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Extensions struct {
+				Code string `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	var codes []string
+	for _, e := range resp.Errors {
+		codes = append(codes, e.Extensions.Code)
+	}
+	return resp.Data, codes
+}
+
+func postJSON(t *testing.T, query string, variables map[string]any) *http.Request {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+const attachFile = `mutation($todoId: ID!, $file: Upload!) { attachFile(todoId: $todoId, file: $file) { id } }`
+
+// postUpload sends attachFile for todoID following the GraphQL multipart
+// request spec.
+func postUpload(t *testing.T, todoID string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	operations, err := json.Marshal(map[string]any{
+		"query":     attachFile,
+		"variables": map[string]any{"todoId": todoID, "file": nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteField("operations", string(operations))
+	w.WriteField("map", `{"0": ["variables.file"]}`)
+	part, err := w.CreateFormFile("0", "notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte("plain text notes"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/query", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestAuthorization(t *testing.T) {
+	const (
+		createUser = `mutation { createUser(input: {name: "carol"}) { id } }`
+		createTodo = `mutation($userId: String!) { createTodo(input: {text: "new", userId: $userId}) { id } }`
+		updateTodo = `mutation($id: ID!) { updateTodo(id: $id, input: {done: true}) { id } }`
+		deleteTodo = `mutation($id: ID!) { deleteTodo(id: $id) { id } }`
+		users      = `{ users { id } }`
+	)
+
+	tests := []struct {
+		name   string
+		claims *auth.Claims
+		// request builds the request for the todo owned by alice.
+		request func(t *testing.T, todoID string) *http.Request
+		want    apperr.Code // empty when the operation succeeds
+	}{
+		{"anonymous users", nil, func(t *testing.T, _ string) *http.Request { return postJSON(t, users, nil) }, apperr.CodeUnauthenticated},
+		{"anonymous createTodo", nil, func(t *testing.T, _ string) *http.Request {
+			return postJSON(t, createTodo, map[string]any{"userId": "alice"})
+		}, apperr.CodeUnauthenticated},
+		{"anonymous updateTodo", nil, func(t *testing.T, id string) *http.Request {
+			return postJSON(t, updateTodo, map[string]any{"id": id})
+		}, apperr.CodeUnauthenticated},
+		{"anonymous attachFile", nil, postUpload, apperr.CodeUnauthenticated},
+
+		{"user lists users", alice, func(t *testing.T, _ string) *http.Request { return postJSON(t, users, nil) }, apperr.CodeForbidden},
+		{"user creates user", alice, func(t *testing.T, _ string) *http.Request { return postJSON(t, createUser, nil) }, apperr.CodeForbidden},
+		{"admin lists users", admin, func(t *testing.T, _ string) *http.Request { return postJSON(t, users, nil) }, ""},
+		{"admin creates user", admin, func(t *testing.T, _ string) *http.Request { return postJSON(t, createUser, nil) }, ""},
+
+		{"createTodo for another user", bob, func(t *testing.T, _ string) *http.Request {
+			return postJSON(t, createTodo, map[string]any{"userId": "alice"})
+		}, apperr.CodeForbidden},
+		{"createTodo for self", alice, func(t *testing.T, _ string) *http.Request {
+			return postJSON(t, createTodo, map[string]any{"userId": "alice"})
+		}, ""},
+		{"createTodo as admin", admin, func(t *testing.T, _ string) *http.Request {
+			return postJSON(t, createTodo, map[string]any{"userId": "alice"})
+		}, ""},
+
+		{"updateTodo by non-owner", bob, func(t *testing.T, id string) *http.Request {
+			return postJSON(t, updateTodo, map[string]any{"id": id})
+		}, apperr.CodeForbidden},
+		{"updateTodo by owner", alice, func(t *testing.T, id string) *http.Request {
+			return postJSON(t, updateTodo, map[string]any{"id": id})
+		}, ""},
+		{"updateTodo by admin", admin, func(t *testing.T, id string) *http.Request {
+			return postJSON(t, updateTodo, map[string]any{"id": id})
+		}, ""},
+
+		{"deleteTodo by non-owner", bob, func(t *testing.T, id string) *http.Request {
+			return postJSON(t, deleteTodo, map[string]any{"id": id})
+		}, apperr.CodeForbidden},
+		{"deleteTodo by owner", alice, func(t *testing.T, id string) *http.Request {
+			return postJSON(t, deleteTodo, map[string]any{"id": id})
+		}, ""},
+		{"deleteTodo by admin", admin, func(t *testing.T, id string) *http.Request {
+			return postJSON(t, deleteTodo, map[string]any{"id": id})
+		}, ""},
+
+		{"attachFile by non-owner", bob, postUpload, apperr.CodeForbidden},
+		{"attachFile by owner", alice, postUpload, ""},
+		{"attachFile by admin", admin, postUpload, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAuthFixture(t)
+			codes := f.exec(t, tt.claims, tt.request(t, f.todoID))
+			switch {
+			case tt.want == "" && len(codes) != 0:
+				t.Errorf("got errors %v, want success", codes)
+			case tt.want != "" && (len(codes) != 1 || codes[0] != string(tt.want)):
+				t.Errorf("got errors %v, want %s", codes, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizationLeavesOthersTodosUntouched(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+
+	f.exec(t, bob, postJSON(t, `mutation($id: ID!) { updateTodo(id: $id, input: {text: "bob was here"}) { id } }`, map[string]any{"id": f.todoID}))
+	f.exec(t, bob, postJSON(t, `mutation($id: ID!) { deleteTodo(id: $id) { id } }`, map[string]any{"id": f.todoID}))
+	f.exec(t, bob, postUpload(t, f.todoID))
+
+	todo, err := f.resolver.Store.Todo(ctx, f.todoID)
+	if err != nil {
+		t.Fatalf("todo after bob's mutations: %v", err)
+	}
+	if todo.Text != "alice's todo" {
+		t.Errorf("text = %q, want it unchanged", todo.Text)
+	}
+	attachments, err := f.resolver.Store.AttachmentsByTodoIDs(ctx, []string{f.todoID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 0 {
+		t.Errorf("got %d attachments, want none", len(attachments))
+	}
+}
+
+func TestSubscriptionsDeliverOnlyPermittedTodos(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name    string
+		claims  *auth.Claims
+		userID  *string
+		want    []string // owners of the todos received, in order
+		wantErr apperr.Code
+	}{
+		{name: "anonymous", wantErr: apperr.CodeUnauthenticated},
+		{name: "own todos", claims: alice, want: []string{"alice"}},
+		{name: "own todos by id", claims: alice, userID: ptr("alice"), want: []string{"alice"}},
+		{name: "another user's todos", claims: alice, userID: ptr("bob"), wantErr: apperr.CodeForbidden},
+		{name: "admin sees everyone", claims: admin, want: []string{"alice", "bob"}},
+		{name: "admin filters by user", claims: admin, userID: ptr("bob"), want: []string{"bob"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAuthFixture(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			subCtx := ctx
+			if tt.claims != nil {
+				subCtx = auth.WithClaims(ctx, tt.claims)
+			}
+
+			ch, err := f.resolver.Subscription().TodoUpdated(subCtx, tt.userID)
+			if tt.wantErr != "" {
+				if code := apperr.CodeOf(err); code != tt.wantErr {
+					t.Fatalf("err = %v (%s), want %s", err, code, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, owner := range []string{"alice", "bob"} {
+				f.resolver.publish(topicTodoUpdated, &model.Todo{ID: owner + "-todo", UserID: owner})
+			}
+			var got []string
+			for range tt.want {
+				select {
+				case todo := <-ch:
+					got = append(got, todo.UserID)
+				case <-time.After(time.Second):
+					t.Fatalf("received %v, want %v", got, tt.want)
+				}
+			}
+			select {
+			case todo := <-ch:
+				t.Fatalf("received unexpected todo of %s", todo.UserID)
+			default:
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("received %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package graph
+
+// representationIDs returns the id of each entity representation, in order.
+func representationIDs[R any](reps []R, id func(R) string) []string {
+	ids := make([]string, len(reps))
+	for i, rep := range reps {
+		ids[i] = id(rep)
+	}
+	return ids
+}
+
+// inOrder arranges found to match ids, as the router expects entities in
+// the order of their representations. IDs that were not found resolve to
+// nil, which becomes a null entity.
+func inOrder[T any](ids []string, found []*T, id func(*T) string) []*T {
+	byID := make(map[string]*T, len(found))
+	for _, v := range found {
+		byID[id(v)] = v
+	}
+	result := make([]*T, len(ids))
+	for i, key := range ids {
+		result[i] = byID[key]
+	}
+	return result
+}
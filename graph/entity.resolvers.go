@@ -0,0 +1,42 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.68
+
+import (
+	"context"
+
+	"github.com/natnael_wondwoesn/GGStarter/graph/dataloader"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+)
+
+// FindManyTodoByIDs is the resolver for the findManyTodoByIDs field.
+func (r *entityResolver) FindManyTodoByIDs(ctx context.Context, reps []*model.TodoByIDsInput) ([]*model.Todo, error) {
+	ids := representationIDs(reps, func(rep *model.TodoByIDsInput) string { return rep.ID })
+	todos, err := r.Store.TodosByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return inOrder(ids, todos, func(t *model.Todo) string { return t.ID }), nil
+}
+
+// FindManyUserByIDs is the resolver for the findManyUserByIDs field.
+func (r *entityResolver) FindManyUserByIDs(ctx context.Context, reps []*model.UserByIDsInput) ([]*model.User, error) {
+	ids := representationIDs(reps, func(rep *model.UserByIDsInput) string { return rep.ID })
+	users, err := r.Store.UsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if loaders := dataloader.For(ctx); loaders != nil {
+		for _, u := range users {
+			loaders.UserByID.Prime(u.ID, u)
+		}
+	}
+	return inOrder(ids, users, func(u *model.User) string { return u.ID }), nil
+}
+
+// Entity returns EntityResolver implementation.
+func (r *Resolver) Entity() EntityResolver { return &entityResolver{r} }
+
+type entityResolver struct{ *Resolver }
@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+)
+
+// Topics published on Resolver.Events by the todo mutations.
+const (
+	topicTodoCreated = "todo.created"
+	topicTodoUpdated = "todo.updated"
+	topicTodoDeleted = "todo.deleted"
+)
+
+// userTopic scopes topic to a single user so subscribers can filter without
+// receiving every event.
+func userTopic(topic, userID string) string {
+	return topic + ":" + userID
+}
+
+// publish sends todo to topic and to the owner-scoped variant of topic.
+func (r *Resolver) publish(topic string, todo *model.Todo) {
+	if r.Events == nil {
+		return
+	}
+	r.Events.Publish(topic, todo)
+	r.Events.Publish(userTopic(topic, todo.UserID), todo)
+}
+
+// subscribeTodos subscribes to topic. Admins receive every user's todos,
+// or only those of userID when given; anyone else receives only their own
+// and may not name another user.
+func (r *Resolver) subscribeTodos(ctx context.Context, topic string, userID *string) (<-chan *model.Todo, error) {
+	claims := auth.ForContext(ctx)
+	if claims == nil {
+		return nil, errUnauthenticated
+	}
+	if !hasRole(claims, model.RoleAdmin) {
+		if userID != nil && *userID != claims.UserID() {
+			return nil, apperr.Forbidden("you may only subscribe to your own todos")
+		}
+		return r.Events.Subscribe(ctx, userTopic(topic, claims.UserID())), nil
+	}
+	if userID != nil {
+		return r.Events.Subscribe(ctx, userTopic(topic, *userID)), nil
+	}
+	return r.Events.Subscribe(ctx, topic), nil
+}
+
+// todoTypes are the types of cached responses a todo mutation can make
+// stale: the todo itself, the connections listing it and the User whose
+// todos field pages them.
+var todoTypes = []string{"Todo", "TodoConnection", "User"}
+
+// invalidate drops cached responses containing any of types.
+func (r *Resolver) invalidate(ctx context.Context, types ...string) {
+	if r.Responses == nil {
+		return
+	}
+	r.Responses.Invalidate(ctx, types...)
+}
@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/natnael_wondwoesn/GGStarter/graph/persisted"
+	"github.com/vektah/gqlparser/v2"
+)
+
+// TestExamplesValidate checks that the example operations, opened as IDE
+// tabs and extracted into persisted operation manifests, are valid against
+// the schema.
+func TestExamplesValidate(t *testing.T) {
+	dir := filepath.Join("..", "examples")
+	schema := NewExecutableSchema(Config{}).Schema()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.graphql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no examples found")
+	}
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, errs := gqlparser.LoadQuery(schema, string(b)); errs != nil {
+			t.Errorf("%s: %v", filepath.Base(path), errs)
+		}
+	}
+
+	manifest, err := persisted.Extract(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for hash, doc := range manifest {
+		if _, errs := gqlparser.LoadQuery(schema, doc); errs != nil {
+			t.Errorf("manifest operation %s: %v", hash, errs)
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/natnael_wondwoesn/GGStarter/graph/dataloader"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+func newTestServer(db store.Store) *handler.Server {
+	cfg := Config{Resolvers: &Resolver{Store: db, Events: pubsub.NewHub[*model.Todo]()}}
+	cfg.Directives.Auth = Auth
+	cfg.Directives.HasRole = HasRole
+	srv := handler.New(NewExecutableSchema(cfg))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(dataloader.Extension{Store: db})
+	return srv
+}
+
+// do runs query against srv and decodes its data into data.
+func do(t *testing.T, srv http.Handler, query string, variables map[string]any, data any) {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	if len(resp.Errors) != 0 {
+		t.Fatalf("query failed: %+v", resp.Errors)
+	}
+	if err := json.Unmarshal(resp.Data, data); err != nil {
+		t.Fatalf("decode data %s: %v", resp.Data, err)
+	}
+}
+
+// reviews is a second subgraph contributing a field to our User entity.
+const reviews = `
+extend schema @link(url: "https://specs.apollo.dev/federation/v2.3", import: ["@key"])
+
+type User @key(fields: "id") {
+  id: ID!
+  reviews: [Review!]!
+}
+
+type Review {
+  id: ID!
+  body: String!
+  author: User!
+}
+
+type Query {
+  reviews: [Review!]!
+}
+`
+
+// composeScript composes the subgraphs given as JSON on stdin with Apollo's
+// composition library, printing the supergraph SDL or the composition
+// errors.
+const composeScript = `
+const { composeServices } = require("@apollo/composition");
+const { parse } = require("graphql");
+let input = "";
+process.stdin.on("data", (d) => (input += d)).on("end", () => {
+  const result = composeServices(JSON.parse(input).map((s) => ({
+    name: s.name, url: "http://" + s.name, typeDefs: parse(s.sdl),
+  })));
+  if (result.errors) {
+    for (const e of result.errors) console.error(e.message);
+    process.exit(1);
+  }
+  process.stdout.write(result.supergraphSdl);
+});
+`
+
+// composer returns a function composing subgraphs with @apollo/composition,
+// skipping the test when node or the package is unavailable. Point NODE_PATH
+// at a node_modules holding @apollo/composition and graphql to run it.
+func composer(t *testing.T) func(subgraphs ...[2]string) (string, error) {
+	t.Helper()
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+	check := exec.Command(node, "-e", `require.resolve("@apollo/composition"); require.resolve("graphql")`)
+	if err := check.Run(); err != nil {
+		t.Skip("@apollo/composition is not installed; set NODE_PATH to run composition tests")
+	}
+
+	return func(subgraphs ...[2]string) (string, error) {
+		var input []map[string]string
+		for _, s := range subgraphs {
+			input = append(input, map[string]string{"name": s[0], "sdl": s[1]})
+		}
+		data, err := json.Marshal(input)
+		if err != nil {
+			return "", err
+		}
+		cmd := exec.Command(node, "-e", composeScript)
+		cmd.Stdin = bytes.NewReader(data)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("%v: %s", err, stderr.String())
+		}
+		return stdout.String(), nil
+	}
+}
+
+func TestServiceSDLComposesWithAnotherSubgraph(t *testing.T) {
+	compose := composer(t)
+
+	srv := newTestServer(store.NewMemory())
+	var data struct {
+		Service struct {
+			SDL string `json:"sdl"`
+		} `json:"_service"`
+	}
+	do(t, srv, "{ _service { sdl } }", nil, &data)
+
+	supergraph, err := compose([2]string{"todos", data.Service.SDL}, [2]string{"reviews", reviews})
+	if err != nil {
+		t.Fatalf("composition failed: %v", err)
+	}
+	for _, want := range []string{"reviews: [Review!]!", "todos(", "author: User!"} {
+		if !strings.Contains(supergraph, want) {
+			t.Errorf("supergraph lacks %q:\n%s", want, supergraph)
+		}
+	}
+
+	// A second, conflicting owner of User.name must fail composition.
+	conflict := strings.Replace(reviews, "id: ID!\n  reviews", "id: ID!\n  name: Int!\n  reviews", 1)
+	if _, err := compose([2]string{"todos", data.Service.SDL}, [2]string{"reviews", conflict}); err == nil {
+		t.Error("a subgraph redefining User.name composed")
+	}
+}
+
+func TestEntitiesResolveInRepresentationOrder(t *testing.T) {
+	ctx := context.Background()
+	db := store.NewMemory()
+	var users []*model.User
+	for _, name := range []string{"Ann", "Bob"} {
+		u := &model.User{Name: name}
+		if err := db.CreateUser(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, u)
+	}
+	var todos []*model.Todo
+	for _, text := range []string{"first", "second"} {
+		todo := &model.Todo{Text: text, UserID: users[0].ID}
+		if err := db.CreateTodo(ctx, todo); err != nil {
+			t.Fatal(err)
+		}
+		todos = append(todos, todo)
+	}
+
+	rep := func(typename, id string) map[string]any {
+		return map[string]any{"__typename": typename, "id": id}
+	}
+	// Types are interleaved and reversed, with a missing ID of each type.
+	representations := []any{
+		rep("User", users[1].ID),
+		rep("Todo", todos[1].ID),
+		rep("User", "missing-user"),
+		rep("Todo", "missing-todo"),
+		rep("User", users[0].ID),
+		rep("Todo", todos[0].ID),
+	}
+	var data struct {
+		Entities []*struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			Text string `json:"text"`
+		} `json:"_entities"`
+	}
+	do(t, newTestServer(db), `query ($representations: [_Any!]!) {
+		_entities(representations: $representations) {
+			... on User { id name }
+			... on Todo { id text }
+		}
+	}`, map[string]any{"representations": representations}, &data)
+
+	want := []string{"Bob", "second", "", "", "Ann", "first"}
+	if len(data.Entities) != len(want) {
+		t.Fatalf("got %d entities for %d representations", len(data.Entities), len(want))
+	}
+	for i, e := range data.Entities {
+		id := representations[i].(map[string]any)["id"]
+		if want[i] == "" {
+			if e != nil {
+				t.Errorf("entity %d: got %+v for %s, want null", i, e, id)
+			}
+			continue
+		}
+		if e == nil || e.ID != id || e.Name+e.Text != want[i] {
+			t.Errorf("entity %d: got %+v, want %s (%s)", i, e, id, want[i])
+		}
+	}
+}
+
+// federationPrelude declares the federation v2 definitions a subgraph may
+// use without defining them.
+const federationPrelude = `
+directive @link(url: String!, as: String, for: link__Purpose, import: [link__Import]) repeatable on SCHEMA
+directive @key(fields: federation__FieldSet!, resolvable: Boolean = true) repeatable on OBJECT | INTERFACE
+directive @shareable repeatable on OBJECT | FIELD_DEFINITION
+scalar link__Import
+scalar federation__FieldSet
+enum link__Purpose { SECURITY EXECUTION }
+`
+
+// federationDirectives are the prelude's directives that must be imported
+// by @link before use.
+var federationDirectives = []string{"key", "shareable"}
+
+// loadSubgraph parses and validates sdl against the federation prelude and
+// returns the problems composition would report for it alone: federation
+// directives used without an @link import, @key selections naming missing
+// fields, and definitions of the types and fields the router adds.
+func loadSubgraph(t *testing.T, name, sdl string) (*ast.Schema, []string) {
+	t.Helper()
+	schema, err := gqlparser.LoadSchema(
+		&ast.Source{Name: "federation", Input: federationPrelude, BuiltIn: true},
+		&ast.Source{Name: name, Input: sdl},
+	)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+
+	var problems []string
+	imported := map[string]bool{}
+	for _, link := range schema.SchemaDirectives.ForNames("link") {
+		if arg := link.Arguments.ForName("import"); arg != nil {
+			for _, imp := range arg.Value.Children {
+				imported[strings.TrimPrefix(imp.Value.Raw, "@")] = true
+			}
+		}
+	}
+	checkImported := func(where string, directives ast.DirectiveList) {
+		for _, d := range directives {
+			if slices.Contains(federationDirectives, d.Name) && !imported[d.Name] {
+				problems = append(problems, fmt.Sprintf("%s uses @%s without importing it", where, d.Name))
+			}
+		}
+	}
+
+	for _, def := range schema.Types {
+		if def.BuiltIn {
+			continue
+		}
+		if slices.Contains([]string{"_Service", "_Any", "_Entity"}, def.Name) {
+			problems = append(problems, "defines "+def.Name)
+		}
+		checkImported(def.Name, def.Directives)
+		for _, field := range def.Fields {
+			checkImported(def.Name+"."+field.Name, field.Directives)
+		}
+		for _, key := range def.Directives.ForNames("key") {
+			fields := key.Arguments.ForName("fields").Value.Raw
+			for _, missing := range missingFields(schema, def, fields) {
+				problems = append(problems, fmt.Sprintf("%s @key(fields: %q) selects missing field %s", def.Name, fields, missing))
+			}
+		}
+	}
+	if q := schema.Query; q != nil {
+		for _, f := range []string{"_service", "_entities"} {
+			if q.Fields.ForName(f) != nil {
+				problems = append(problems, "defines Query."+f)
+			}
+		}
+	}
+	return schema, problems
+}
+
+// missingFields returns the fields of the selection set fields that def
+// lacks, recursing into object fields.
+func missingFields(schema *ast.Schema, def *ast.Definition, fields string) []string {
+	doc, err := parser.ParseQuery(&ast.Source{Input: "{" + fields + "}"})
+	if err != nil {
+		return []string{fields}
+	}
+	var missing []string
+	var walk func(def *ast.Definition, set ast.SelectionSet)
+	walk = func(def *ast.Definition, set ast.SelectionSet) {
+		for _, sel := range set {
+			f, ok := sel.(*ast.Field)
+			if !ok {
+				continue
+			}
+			field := def.Fields.ForName(f.Name)
+			if field == nil {
+				missing = append(missing, def.Name+"."+f.Name)
+				continue
+			}
+			if len(f.SelectionSet) > 0 {
+				walk(schema.Types[field.Type.Name()], f.SelectionSet)
+			}
+		}
+	}
+	walk(def, doc.Operations[0].SelectionSet)
+	return missing
+}
+
+// compositionConflicts returns the fields both subgraphs define that
+// federation v2 composition rejects: a field outside the type's @key must
+// be @shareable in both, and a shared field must have the same type.
+func compositionConflicts(a, b *ast.Schema) []string {
+	keyFields := func(def *ast.Definition) map[string]bool {
+		fields := map[string]bool{}
+		for _, key := range def.Directives.ForNames("key") {
+			for _, f := range strings.Fields(key.Arguments.ForName("fields").Value.Raw) {
+				fields[f] = true
+			}
+		}
+		return fields
+	}
+	shareable := func(def *ast.Definition, field *ast.FieldDefinition) bool {
+		return def.Directives.ForName("shareable") != nil || field.Directives.ForName("shareable") != nil
+	}
+
+	var conflicts []string
+	for name, defA := range a.Types {
+		defB := b.Types[name]
+		if defA.BuiltIn || defB == nil || defA.Kind != ast.Object || defB.Kind != ast.Object {
+			continue
+		}
+		keysA, keysB := keyFields(defA), keyFields(defB)
+		for _, fieldA := range defA.Fields {
+			fieldB := defB.Fields.ForName(fieldA.Name)
+			if fieldB == nil || strings.HasPrefix(fieldA.Name, "__") {
+				continue
+			}
+			coord := name + "." + fieldA.Name
+			switch {
+			case fieldA.Type.String() != fieldB.Type.String():
+				conflicts = append(conflicts, fmt.Sprintf("%s is %s in one subgraph and %s in the other", coord, fieldA.Type, fieldB.Type))
+			case keysA[fieldA.Name] && keysB[fieldA.Name]:
+			case !shareable(defA, fieldA) || !shareable(defB, fieldB):
+				conflicts = append(conflicts, coord+" is resolved by both subgraphs but not @shareable in both")
+			}
+		}
+	}
+	slices.Sort(conflicts)
+	return conflicts
+}
+
+// TestServiceSDLIsAValidSubgraph checks the _service SDL with the rules
+// composition applies, without node. TestServiceSDLComposesWithAnotherSubgraph
+// runs Apollo's composition itself where it is installed.
+func TestServiceSDLIsAValidSubgraph(t *testing.T) {
+	var data struct {
+		Service struct {
+			SDL string `json:"sdl"`
+		} `json:"_service"`
+	}
+	do(t, newTestServer(store.NewMemory()), "{ _service { sdl } }", nil, &data)
+
+	todos, problems := loadSubgraph(t, "todos", data.Service.SDL)
+	for _, p := range problems {
+		t.Errorf("todos: %s", p)
+	}
+	for _, entity := range []string{"User", "Todo"} {
+		def := todos.Types[entity]
+		if def == nil || def.Directives.ForName("key") == nil {
+			t.Errorf("%s is not an entity in the _service SDL", entity)
+		}
+	}
+
+	other, problems := loadSubgraph(t, "reviews", reviews)
+	for _, p := range problems {
+		t.Errorf("reviews: %s", p)
+	}
+	if conflicts := compositionConflicts(todos, other); len(conflicts) != 0 {
+		t.Errorf("conflicts with the reviews subgraph: %q", conflicts)
+	}
+
+	// A second, conflicting owner of User.name must be rejected.
+	conflict := strings.Replace(reviews, "id: ID!\n  reviews", "id: ID!\n  name: Int!\n  reviews", 1)
+	other, _ = loadSubgraph(t, "reviews", conflict)
+	want := []string{"User.name is String! in one subgraph and Int! in the other"}
+	if got := compositionConflicts(todos, other); !slices.Equal(got, want) {
+		t.Errorf("conflicts with a subgraph redefining User.name = %q, want %q", got, want)
+	}
+}
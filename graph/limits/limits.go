@@ -0,0 +1,164 @@
+// Package limits rejects operations that are too deep, too expensive or too
+// wide before any resolver runs.
+package limits
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const extensionName = "QueryLimits"
+
+// Error codes set in extensions.code when a limit is exceeded.
+const (
+	CodeDepthLimit      = "DEPTH_LIMIT_EXCEEDED"
+	CodeComplexityLimit = "COMPLEXITY_LIMIT_EXCEEDED"
+	CodeAliasLimit      = "ALIAS_LIMIT_EXCEEDED"
+	CodeRootFieldLimit  = "ROOT_FIELD_LIMIT_EXCEEDED"
+)
+
+// Limit kinds set in extensions.kind, next to the measured value and the
+// limit it exceeded.
+const (
+	KindDepth      = "depth"
+	KindComplexity = "complexity"
+	KindAliases    = "aliases"
+	KindRootFields = "rootFields"
+)
+
+// Limits configures the Extension. A zero value disables that check.
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+	MaxAliases    int
+	MaxRootFields int
+}
+
+// Stats are the measured values of an operation.
+type Stats struct {
+	Depth      int
+	Complexity int
+	Aliases    int
+	RootFields int
+}
+
+// Extension is a gqlgen handler extension enforcing Limits.
+type Extension struct {
+	Limits Limits
+	es     graphql.ExecutableSchema
+}
+
+var (
+	_ graphql.HandlerExtension        = (*Extension)(nil)
+	_ graphql.OperationContextMutator = (*Extension)(nil)
+)
+
+// New returns an Extension enforcing l.
+func New(l Limits) *Extension {
+	return &Extension{Limits: l}
+}
+
+func (e *Extension) ExtensionName() string {
+	return extensionName
+}
+
+func (e *Extension) Validate(schema graphql.ExecutableSchema) error {
+	e.es = schema
+	return nil
+}
+
+func (e *Extension) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	op := opCtx.Operation
+	stats := &Stats{
+		Depth:      selectionDepth(op.SelectionSet),
+		Complexity: complexity.Calculate(e.es, op, opCtx.Variables),
+		Aliases:    countAliases(op.SelectionSet),
+		RootFields: len(collectFields(op.SelectionSet)),
+	}
+	opCtx.Stats.SetExtension(extensionName, stats)
+
+	checks := []struct {
+		value, limit     int
+		what, kind, code string
+	}{
+		{stats.Depth, e.Limits.MaxDepth, "depth", KindDepth, CodeDepthLimit},
+		{stats.Complexity, e.Limits.MaxComplexity, "complexity", KindComplexity, CodeComplexityLimit},
+		{stats.Aliases, e.Limits.MaxAliases, "alias count", KindAliases, CodeAliasLimit},
+		{stats.RootFields, e.Limits.MaxRootFields, "root field count", KindRootFields, CodeRootFieldLimit},
+	}
+	for _, c := range checks {
+		if c.limit > 0 && c.value > c.limit {
+			err := gqlerror.Errorf("operation %s is %d, which exceeds the limit of %d", c.what, c.value, c.limit)
+			errcode.Set(err, c.code)
+			err.Extensions["kind"] = c.kind
+			err.Extensions["value"] = c.value
+			err.Extensions["limit"] = c.limit
+			return err
+		}
+	}
+	return nil
+}
+
+// StatsFor returns the measurements of the current operation, or nil when the
+// extension is not installed.
+func StatsFor(ctx context.Context) *Stats {
+	if !graphql.HasOperationContext(ctx) {
+		return nil
+	}
+	return OperationStats(graphql.GetOperationContext(ctx))
+}
+
+// OperationStats is StatsFor for extensions that run before the operation
+// context is attached to ctx, such as later OperationContextMutators.
+func OperationStats(opCtx *graphql.OperationContext) *Stats {
+	s, _ := opCtx.Stats.GetExtension(extensionName).(*Stats)
+	return s
+}
+
+// selectionDepth returns the number of nested field levels. Introspection
+// subtrees are ignored so that tooling is not affected by MaxDepth.
+func selectionDepth(set ast.SelectionSet) int {
+	depth := 0
+	for _, f := range collectFields(set) {
+		if f.Name == "__schema" || f.Name == "__type" {
+			continue
+		}
+		depth = max(depth, 1+selectionDepth(f.SelectionSet))
+	}
+	return depth
+}
+
+func countAliases(set ast.SelectionSet) int {
+	n := 0
+	for _, f := range collectFields(set) {
+		if f.Alias != "" && f.Alias != f.Name {
+			n++
+		}
+		n += countAliases(f.SelectionSet)
+	}
+	return n
+}
+
+// collectFields flattens fragment spreads and inline fragments into the
+// fields they contribute at this level.
+func collectFields(set ast.SelectionSet) []*ast.Field {
+	var fields []*ast.Field
+	for _, sel := range set {
+		switch s := sel.(type) {
+		case *ast.Field:
+			fields = append(fields, s)
+		case *ast.InlineFragment:
+			fields = append(fields, collectFields(s.SelectionSet)...)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				fields = append(fields, collectFields(s.Definition.SelectionSet)...)
+			}
+		}
+	}
+	return fields
+}
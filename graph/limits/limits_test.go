@@ -0,0 +1,107 @@
+package limits_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/natnael_wondwoesn/GGStarter/graph"
+	"github.com/natnael_wondwoesn/GGStarter/graph/limits"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+)
+
+type gqlError struct {
+	Message    string         `json:"message"`
+	Extensions map[string]any `json:"extensions"`
+}
+
+// run executes query against the API's schema and complexity functions
+// with l enforced, returning the errors of the response.
+func run(t *testing.T, l limits.Limits, query string) []gqlError {
+	t.Helper()
+	cfg := graph.Config{Resolvers: &graph.Resolver{Store: store.NewMemory(), Events: pubsub.NewHub[*model.Todo]()}}
+	graph.SetComplexity(&cfg.Complexity)
+	srv := handler.New(graph.NewExecutableSchema(cfg))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(limits.New(l))
+
+	body, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var resp struct {
+		Errors []gqlError `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	return resp.Errors
+}
+
+// nested selects every user's todos under every todo.
+const nested = `{ todos { user { todos(first: 100) { edges { node { id } } } } } }`
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits limits.Limits
+		query  string
+		code   string
+		kind   string
+		value  int
+		limit  int
+	}{
+		{"depth", limits.Limits{MaxDepth: 5}, nested, limits.CodeDepthLimit, limits.KindDepth, 6, 5},
+		{"aliases", limits.Limits{MaxAliases: 1}, `{ a: todos { id } b: todos { id } }`, limits.CodeAliasLimit, limits.KindAliases, 2, 1},
+		{"root fields", limits.Limits{MaxRootFields: 1}, `{ todos { id } me { id } }`, limits.CodeRootFieldLimit, limits.KindRootFields, 2, 1},
+		// node and id cost 2, edges 3, a page of 100 of them 301, the
+		// user 302, and the unpaginated todos list a page of users.
+		{"complexity", limits.Limits{MaxComplexity: 1000}, nested, limits.CodeComplexityLimit, limits.KindComplexity, 1 + 302*100, 1000},
+		{"complexity of an unpaginated list", limits.Limits{MaxComplexity: 100}, `{ users { id } }`, limits.CodeComplexityLimit, limits.KindComplexity, 101, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := run(t, tt.limits, tt.query)
+			if len(errs) != 1 {
+				t.Fatalf("got errors %+v, want one", errs)
+			}
+			ext := errs[0].Extensions
+			if ext["code"] != tt.code || ext["kind"] != tt.kind {
+				t.Errorf("code %v, kind %v; want %s, %s", ext["code"], ext["kind"], tt.code, tt.kind)
+			}
+			if ext["value"] != float64(tt.value) || ext["limit"] != float64(tt.limit) {
+				t.Errorf("value %v, limit %v; want %d, %d", ext["value"], ext["limit"], tt.value, tt.limit)
+			}
+			if _, ok := ext["cost"]; ok {
+				t.Errorf("extensions report a cost: %v", ext)
+			}
+		})
+	}
+}
+
+func TestOperationsWithinLimitsRun(t *testing.T) {
+	l := limits.Limits{MaxDepth: 6, MaxComplexity: 30201, MaxAliases: 2, MaxRootFields: 2}
+	for _, query := range []string{
+		nested,
+		`{ a: todos { id } b: todos { id } }`,
+		// Introspection does not count towards the depth.
+		`{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`,
+	} {
+		if errs := run(t, l, query); len(errs) != 0 {
+			t.Errorf("%s: %+v", query, errs)
+		}
+	}
+}
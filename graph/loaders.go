@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/natnael_wondwoesn/GGStarter/graph/dataloader"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+)
+
+// loadUser reads a user through the request's DataLoader when one is
+// installed, falling back to the store otherwise.
+func (r *Resolver) loadUser(ctx context.Context, id string) (*model.User, error) {
+	if loaders := dataloader.For(ctx); loaders != nil {
+		return loaders.UserByID.Load(ctx, id)
+	}
+	return r.Store.User(ctx, id)
+}
+
+// loadTodosByUser reads all todos owned by userID, batching across users
+// when a DataLoader is installed.
+func (r *Resolver) loadTodosByUser(ctx context.Context, userID string) ([]*model.Todo, error) {
+	if loaders := dataloader.For(ctx); loaders != nil {
+		return loaders.TodosByUser.Load(ctx, userID)
+	}
+	return r.Store.TodosByUserIDs(ctx, []string{userID})
+}
+
+// loadAttachments reads the attachments of todoID, batching across todos
+// when a DataLoader is installed.
+func (r *Resolver) loadAttachments(ctx context.Context, todoID string) ([]*model.Attachment, error) {
+	if loaders := dataloader.For(ctx); loaders != nil {
+		return loaders.AttachmentsByTodo.Load(ctx, todoID)
+	}
+	return r.Store.AttachmentsByTodoIDs(ctx, []string{todoID})
+}
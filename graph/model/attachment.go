@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Attachment is a file uploaded to a todo. The file itself is kept in
+// storage under Key; its URL is resolved from there on demand.
+type Attachment struct {
+	ID          string    `json:"id"`
+	TodoID      string    `json:"todoId"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"contentType"`
+	Size        int32     `json:"size"`
+	Key         string    `json:"-"`
+	CreatedAt   time.Time `json:"-"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Todo is bound in gqlgen.yml instead of being generated so that the owning
+// user is referenced by ID and resolved through the user DataLoader.
+type Todo struct {
+	ID     string `json:"id"`
+	Text   string `json:"text"`
+	Done   bool   `json:"done"`
+	UserID string `json:"userId"`
+
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	DueAt     *time.Time `json:"dueAt,omitempty"`
+}
+
+// IsEntity marks Todo as a federation entity.
+func (Todo) IsEntity() {}
@@ -0,0 +1,15 @@
+package model
+
+// User is bound in gqlgen.yml so that todos are loaded by a field resolver
+// rather than stored on the struct. Credentials are never exposed through
+// the schema.
+type User struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Roles        []Role `json:"roles"`
+	Email        string `json:"-"`
+	PasswordHash string `json:"-"`
+}
+
+// IsEntity marks User as a federation entity.
+func (User) IsEntity() {}
@@ -0,0 +1,243 @@
+// Package oplog is a gqlgen handler extension that writes one structured log
+// line per GraphQL operation and flags slow resolvers.
+package oplog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/natnael_wondwoesn/GGStarter/internal/requestid"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.uber.org/zap"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are matched case-insensitively as substrings of argument
+// and input field names whose values must not be logged.
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "apikey", "api_key"}
+
+// Extension logs operations to Logger. Resolvers taking longer than
+// SlowResolverThreshold are logged individually; zero disables that.
+type Extension struct {
+	Logger                *zap.Logger
+	SlowResolverThreshold time.Duration
+}
+
+var (
+	_ graphql.HandlerExtension    = Extension{}
+	_ graphql.ResponseInterceptor = Extension{}
+	_ graphql.FieldInterceptor    = Extension{}
+)
+
+func (Extension) ExtensionName() string {
+	return "OperationLogger"
+}
+
+func (Extension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil || !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+	opCtx := graphql.GetOperationContext(ctx)
+
+	fields := append(operationFields(ctx, opCtx),
+		zap.String("query_hash", hashQuery(opCtx.RawQuery)),
+		zap.Any("variables", redactVariables(opCtx)),
+		zap.Duration("duration", time.Since(opCtx.Stats.OperationStart)),
+		zap.Int("errors", len(resp.Errors)),
+	)
+
+	// Subscriptions produce a response per event; only queries and
+	// mutations are logged at info level.
+	if opCtx.Operation != nil && opCtx.Operation.Operation == "subscription" {
+		e.Logger.Debug("graphql subscription event", fields...)
+	} else {
+		e.Logger.Info("graphql operation", fields...)
+	}
+	return resp
+}
+
+func (e Extension) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	if e.SlowResolverThreshold <= 0 {
+		return next(ctx)
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	res, err := next(ctx)
+	if elapsed := time.Since(start); elapsed >= e.SlowResolverThreshold {
+		fields := []zap.Field{
+			zap.String("field", fc.Object+"."+fc.Field.Name),
+			zap.String("path", fc.Path().String()),
+			zap.Duration("duration", elapsed),
+			zap.Duration("threshold", e.SlowResolverThreshold),
+		}
+		if graphql.HasOperationContext(ctx) {
+			fields = append(fields, operationFields(ctx, graphql.GetOperationContext(ctx))...)
+		}
+		e.Logger.Warn("slow resolver", fields...)
+	}
+	return res, err
+}
+
+func operationFields(ctx context.Context, opCtx *graphql.OperationContext) []zap.Field {
+	var opType string
+	name := opCtx.OperationName
+	if opCtx.Operation != nil {
+		opType = string(opCtx.Operation.Operation)
+		if name == "" {
+			name = opCtx.Operation.Name
+		}
+	}
+	return []zap.Field{
+		zap.String("operation", name),
+		zap.String("operation_type", opType),
+		zap.String("client_name", clientName(opCtx)),
+		zap.String("request_id", requestid.FromContext(ctx)),
+	}
+}
+
+func clientName(opCtx *graphql.OperationContext) string {
+	if name := opCtx.Headers.Get("Apollographql-Client-Name"); name != "" {
+		return name
+	}
+	return opCtx.Headers.Get("X-Client-Name")
+}
+
+func hashQuery(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// redactVariables returns a copy of the operation's variables with the
+// values of sensitive arguments and input fields replaced. Clients name
+// variables as they please, so a variable is judged by the arguments and
+// input fields it is passed to; only a variable the operation does not use
+// falls back to its own name.
+func redactVariables(opCtx *graphql.OperationContext) map[string]any {
+	bound := boundNames(opCtx.Doc, opCtx.Operation)
+	out := make(map[string]any, len(opCtx.Variables))
+	for name, v := range opCtx.Variables {
+		names, ok := bound[name]
+		if !ok {
+			names = []string{name}
+		}
+		if slices.ContainsFunc(names, isSensitive) {
+			out[name] = redacted
+		} else {
+			out[name] = redact(v)
+		}
+	}
+	return out
+}
+
+// boundNames maps each variable of op to the names of the arguments and
+// input fields it is passed to, including through fragments.
+func boundNames(doc *ast.QueryDocument, op *ast.OperationDefinition) map[string][]string {
+	bound := map[string][]string{}
+	if op == nil {
+		return bound
+	}
+	seen := map[string]bool{}
+
+	var value func(name string, v *ast.Value)
+	value = func(name string, v *ast.Value) {
+		if v == nil {
+			return
+		}
+		if v.Kind == ast.Variable {
+			bound[v.Raw] = append(bound[v.Raw], name)
+			return
+		}
+		for _, child := range v.Children {
+			if v.Kind == ast.ObjectValue {
+				value(child.Name, child.Value)
+			} else {
+				value(name, child.Value)
+			}
+		}
+	}
+	directives := func(list ast.DirectiveList) {
+		for _, d := range list {
+			for _, arg := range d.Arguments {
+				value(arg.Name, arg.Value)
+			}
+		}
+	}
+	var selections func(set ast.SelectionSet)
+	selections = func(set ast.SelectionSet) {
+		for _, sel := range set {
+			switch sel := sel.(type) {
+			case *ast.Field:
+				for _, arg := range sel.Arguments {
+					value(arg.Name, arg.Value)
+				}
+				directives(sel.Directives)
+				selections(sel.SelectionSet)
+			case *ast.InlineFragment:
+				directives(sel.Directives)
+				selections(sel.SelectionSet)
+			case *ast.FragmentSpread:
+				directives(sel.Directives)
+				if seen[sel.Name] {
+					continue
+				}
+				seen[sel.Name] = true
+				if def := doc.Fragments.ForName(sel.Name); def != nil {
+					directives(def.Directives)
+					selections(def.SelectionSet)
+				}
+			}
+		}
+	}
+	directives(op.Directives)
+	selections(op.SelectionSet)
+	return bound
+}
+
+// redact returns a copy of v with the values of sensitive keys replaced.
+func redact(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, inner := range val {
+			if isSensitive(k) {
+				out[k] = redacted
+			} else {
+				out[k] = redact(inner)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, inner := range val {
+			out[i] = redact(inner)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package oplog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+func TestRedactVariablesByBoundName(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		query     string
+		variables map[string]any
+		want      map[string]any
+	}{
+		{
+			name:      "renamed variable",
+			query:     `mutation($t: String!) { refreshToken(token: $t) { accessToken } }`,
+			variables: map[string]any{"t": "secret-refresh-token"},
+			want:      map[string]any{"t": redacted},
+		},
+		{
+			name:      "input object variable",
+			query:     `mutation($in: LoginInput!) { login(input: $in) { accessToken } }`,
+			variables: map[string]any{"in": map[string]any{"email": "ada@example.com", "password": "hunter22"}},
+			want:      map[string]any{"in": map[string]any{"email": "ada@example.com", "password": redacted}},
+		},
+		{
+			name:      "variables inside a literal",
+			query:     `mutation($e: Email!, $p: String!) { login(input: {email: $e, password: $p}) { accessToken } }`,
+			variables: map[string]any{"e": "ada@example.com", "p": "hunter22"},
+			want:      map[string]any{"e": "ada@example.com", "p": redacted},
+		},
+		{
+			name:      "variable in a fragment",
+			query:     `mutation($x: String!) { ...F } fragment F on Mutation { logout(refreshToken: $x) }`,
+			variables: map[string]any{"x": "secret-refresh-token"},
+			want:      map[string]any{"x": redacted},
+		},
+		{
+			name:      "list argument",
+			query:     `mutation($a: String!, $b: String!) { revoke(tokens: [$a, $b]) }`,
+			variables: map[string]any{"a": "one", "b": "two"},
+			want:      map[string]any{"a": redacted, "b": redacted},
+		},
+		{
+			name:      "sensitive variable name on a harmless argument",
+			query:     `query($token: ID!) { todo(id: $token) { id } }`,
+			variables: map[string]any{"token": "todo-1"},
+			want:      map[string]any{"token": "todo-1"},
+		},
+		{
+			name:      "unused variable falls back to its name",
+			query:     `query { todos { id } }`,
+			variables: map[string]any{"password": "hunter22", "n": 1},
+			want:      map[string]any{"password": redacted, "n": 1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := parser.ParseQuery(&ast.Source{Input: tc.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+			opCtx := &graphql.OperationContext{Doc: doc, Operation: doc.Operations[0], Variables: tc.variables}
+			if got := redactVariables(opCtx); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("redactVariables = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
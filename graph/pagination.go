@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	cursorPrefix    = "todo:"
+)
+
+func encodeCursor(id string) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + id))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return "", apperr.Invalid("after", "invalid cursor %q", cursor)
+	}
+	return strings.TrimPrefix(string(raw), cursorPrefix), nil
+}
+
+// pageSize returns the number of items a page request yields.
+func pageSize(first *int32) int {
+	if first == nil {
+		return defaultPageSize
+	}
+	return max(0, min(int(*first), maxPageSize))
+}
+
+// paginateTodos slices todos into a Relay-style connection page starting
+// after the todo identified by the after cursor.
+func paginateTodos(todos []*model.Todo, first *int32, after *string) (*model.TodoConnection, error) {
+	limit := pageSize(first)
+
+	start := 0
+	if after != nil {
+		id, err := decodeCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		start = len(todos)
+		for i, t := range todos {
+			if t.ID == id {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	end := min(start+limit, len(todos))
+	conn := &model.TodoConnection{
+		Edges:      make([]*model.TodoEdge, 0, end-start),
+		PageInfo:   &model.PageInfo{HasNextPage: end < len(todos)},
+		TotalCount: int32(len(todos)),
+	}
+	for _, t := range todos[start:end] {
+		conn.Edges = append(conn.Edges, &model.TodoEdge{Cursor: encodeCursor(t.ID), Node: t})
+	}
+	if n := len(conn.Edges); n > 0 {
+		conn.PageInfo.EndCursor = &conn.Edges[n-1].Cursor
+	}
+	return conn, nil
+}
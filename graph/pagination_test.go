@@ -0,0 +1,210 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+)
+
+func todosNamed(n int) []*model.Todo {
+	todos := make([]*model.Todo, n)
+	for i := range todos {
+		todos[i] = &model.Todo{ID: fmt.Sprintf("t%d", i)}
+	}
+	return todos
+}
+
+func TestPaginateTodos(t *testing.T) {
+	todos := todosNamed(maxPageSize + 5)
+	ptr := func(n int32) *int32 { return &n }
+	cursor := func(id string) *string { c := encodeCursor(id); return &c }
+
+	tests := []struct {
+		name     string
+		first    *int32
+		after    *string
+		wantLen  int
+		wantFrom string // ID of the first edge
+		wantNext bool
+	}{
+		{"default page size", nil, nil, defaultPageSize, "t0", true},
+		{"first", ptr(3), nil, 3, "t0", true},
+		{"first above the maximum", ptr(maxPageSize + 50), nil, maxPageSize, "t0", true},
+		{"after", ptr(3), cursor("t4"), 3, "t5", true},
+		{"last page", ptr(10), cursor(fmt.Sprintf("t%d", maxPageSize)), 4, fmt.Sprintf("t%d", maxPageSize+1), false},
+		{"after the last todo", ptr(3), cursor(fmt.Sprintf("t%d", maxPageSize+4)), 0, "", false},
+		{"first zero", ptr(0), nil, 0, "", true},
+		{"first negative", ptr(-1), nil, 0, "", true},
+		// A well-formed cursor for a todo that no longer exists yields an
+		// empty page rather than restarting from the beginning.
+		{"after a deleted todo", ptr(3), cursor("gone"), 0, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := paginateTodos(todos, tt.first, tt.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(conn.Edges) != tt.wantLen {
+				t.Fatalf("got %d edges, want %d", len(conn.Edges), tt.wantLen)
+			}
+			if conn.TotalCount != int32(len(todos)) {
+				t.Errorf("totalCount = %d, want %d", conn.TotalCount, len(todos))
+			}
+			if conn.PageInfo.HasNextPage != tt.wantNext {
+				t.Errorf("hasNextPage = %v, want %v", conn.PageInfo.HasNextPage, tt.wantNext)
+			}
+			if tt.wantLen == 0 {
+				if conn.PageInfo.EndCursor != nil {
+					t.Errorf("endCursor = %q on an empty page, want null", *conn.PageInfo.EndCursor)
+				}
+				return
+			}
+			if got := conn.Edges[0].Node.ID; got != tt.wantFrom {
+				t.Errorf("first edge %s, want %s", got, tt.wantFrom)
+			}
+			last := conn.Edges[len(conn.Edges)-1]
+			if conn.PageInfo.EndCursor == nil || *conn.PageInfo.EndCursor != last.Cursor {
+				t.Errorf("endCursor = %v, want the last edge's cursor %q", conn.PageInfo.EndCursor, last.Cursor)
+			}
+			for _, e := range conn.Edges {
+				if id, err := decodeCursor(e.Cursor); err != nil || id != e.Node.ID {
+					t.Errorf("cursor %q of %s decodes to %q, %v", e.Cursor, e.Node.ID, id, err)
+				}
+			}
+		})
+	}
+}
+
+func TestPaginateTodosRejectsMalformedCursors(t *testing.T) {
+	for _, cursor := range []string{
+		"",
+		"not base64!",
+		base64.StdEncoding.EncodeToString([]byte("t1")),      // no prefix
+		base64.StdEncoding.EncodeToString([]byte("user:t1")), // another type's prefix
+	} {
+		_, err := paginateTodos(todosNamed(3), nil, &cursor)
+		if code := apperr.CodeOf(err); code != apperr.CodeValidation {
+			t.Errorf("cursor %q: err = %v (%s), want %s", cursor, err, code, apperr.CodeValidation)
+		}
+	}
+}
+
+func TestUserTodosPagesThroughEveryTodo(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	want := []string{f.todoID}
+	for i := range 4 {
+		todo := &model.Todo{Text: fmt.Sprintf("todo %d", i), UserID: "alice"}
+		if err := f.resolver.Store.CreateTodo(ctx, todo); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, todo.ID)
+	}
+	// bob's todos must not appear on alice's pages.
+	if err := f.resolver.Store.CreateTodo(ctx, &model.Todo{Text: "bob's", UserID: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+
+	const page = `query($after: String) {
+		user(id: "alice") {
+			todos(first: 2, after: $after) {
+				edges { cursor node { id } }
+				pageInfo { hasNextPage endCursor }
+				totalCount
+			}
+		}
+	}`
+	type response struct {
+		User struct {
+			Todos struct {
+				Edges []struct {
+					Cursor string
+					Node   struct{ ID string }
+				}
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   *string
+				}
+				TotalCount int
+			}
+		}
+	}
+
+	var got []string
+	var after *string
+	for pages := 0; ; pages++ {
+		if pages == len(want) {
+			t.Fatalf("still paging after %d pages: %v", pages, got)
+		}
+		var data response
+		if codes := f.query(t, nil, page, map[string]any{"after": after}, &data); len(codes) != 0 {
+			t.Fatalf("page %d: errors %v", pages, codes)
+		}
+		todos := data.User.Todos
+		if todos.TotalCount != len(want) {
+			t.Errorf("totalCount = %d, want %d", todos.TotalCount, len(want))
+		}
+		for _, e := range todos.Edges {
+			got = append(got, e.Node.ID)
+		}
+		if n := len(todos.Edges); n > 0 && (todos.PageInfo.EndCursor == nil || *todos.PageInfo.EndCursor != todos.Edges[n-1].Cursor) {
+			t.Errorf("page %d: endCursor %v is not the last edge's cursor", pages, todos.PageInfo.EndCursor)
+		}
+		if !todos.PageInfo.HasNextPage {
+			break
+		}
+		after = todos.PageInfo.EndCursor
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("paged through %v, want %v", got, want)
+	}
+
+	tampered := base64.StdEncoding.EncodeToString([]byte("user:alice"))
+	codes := f.query(t, nil, page, map[string]any{"after": tampered}, &response{})
+	if !slices.Equal(codes, []string{string(apperr.CodeValidation)}) {
+		t.Errorf("tampered cursor: errors %v, want %s", codes, apperr.CodeValidation)
+	}
+}
+
+func TestUserQueries(t *testing.T) {
+	ghost := &auth.Claims{}
+	ghost.Subject = "deleted-user"
+
+	tests := []struct {
+		name   string
+		claims *auth.Claims
+		query  string
+		want   string // JSON encoding of the data
+	}{
+		{"user", nil, `{ user(id: "bob") { id name } }`, `{"user":{"id":"bob","name":"bob"}}`},
+		{"missing user", nil, `{ user(id: "carol") { id name } }`, `{"user":null}`},
+		{"users", admin, `{ users { id name } }`, `{"users":[{"id":"alice","name":"alice"},{"id":"bob","name":"bob"}]}`},
+		{"me", alice, `{ me { id name } }`, `{"me":{"id":"alice","name":"alice"}}`},
+		{"me anonymous", nil, `{ me { id name } }`, `{"me":null}`},
+		{"me after deletion", ghost, `{ me { id name } }`, `{"me":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAuthFixture(t)
+			var data map[string]any
+			if codes := f.query(t, tt.claims, tt.query, nil, &data); len(codes) != 0 {
+				t.Fatalf("errors %v", codes)
+			}
+			got, err := json.Marshal(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
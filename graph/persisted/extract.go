@@ -0,0 +1,123 @@
+package persisted
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// Extract parses the .graphql and .gql files under dirs and returns a
+// manifest mapping the Hash of each named operation's document to its text.
+// Each document holds one operation and the fragments it uses, so fragments
+// may be defined in any of the scanned files. Hidden directories and
+// node_modules are skipped.
+func Extract(dirs ...string) (map[string]string, error) {
+	operations := map[string]*ast.OperationDefinition{}
+	fragments := map[string]*ast.FragmentDefinition{}
+
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if name := d.Name(); path != dir && (strings.HasPrefix(name, ".") || name == "node_modules") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if ext := filepath.Ext(path); ext != ".graphql" && ext != ".gql" {
+				return nil
+			}
+
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			doc, err := parser.ParseQuery(&ast.Source{Name: path, Input: string(src)})
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			for _, op := range doc.Operations {
+				if op.Name == "" {
+					return fmt.Errorf("%s: persisted operations must be named", path)
+				}
+				if _, ok := operations[op.Name]; ok {
+					return fmt.Errorf("%s: duplicate operation %q", path, op.Name)
+				}
+				operations[op.Name] = op
+			}
+			for _, frag := range doc.Fragments {
+				if _, ok := fragments[frag.Name]; ok {
+					return fmt.Errorf("%s: duplicate fragment %q", path, frag.Name)
+				}
+				fragments[frag.Name] = frag
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	manifest := make(map[string]string, len(operations))
+	for name, op := range operations {
+		used := map[string]bool{}
+		if err := collectFragments(op.SelectionSet, fragments, used); err != nil {
+			return nil, fmt.Errorf("operation %q: %w", name, err)
+		}
+
+		doc := &ast.QueryDocument{Operations: ast.OperationList{op}}
+		names := make([]string, 0, len(used))
+		for n := range used {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			doc.Fragments = append(doc.Fragments, fragments[n])
+		}
+
+		var buf bytes.Buffer
+		formatter.NewFormatter(&buf).FormatQueryDocument(doc)
+		manifest[Hash(buf.String())] = buf.String()
+	}
+	return manifest, nil
+}
+
+// collectFragments records the fragments spread by set, transitively.
+func collectFragments(set ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, used map[string]bool) error {
+	for _, sel := range set {
+		switch s := sel.(type) {
+		case *ast.Field:
+			if err := collectFragments(s.SelectionSet, fragments, used); err != nil {
+				return err
+			}
+		case *ast.InlineFragment:
+			if err := collectFragments(s.SelectionSet, fragments, used); err != nil {
+				return err
+			}
+		case *ast.FragmentSpread:
+			if used[s.Name] {
+				continue
+			}
+			frag, ok := fragments[s.Name]
+			if !ok {
+				return fmt.Errorf("unknown fragment %q", s.Name)
+			}
+			used[s.Name] = true
+			if err := collectFragments(frag.SelectionSet, fragments, used); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package persisted
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractExamplesLoads(t *testing.T) {
+	manifest, err := Extract(filepath.Join("..", "..", "examples"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "ops.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Load rejects any entry whose key is not the Hash of its document.
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != len(manifest) {
+		t.Errorf("loaded %d operations, extracted %d", m.Len(), len(manifest))
+	}
+	for _, name := range []string{"Login", "Me", "Todos"} {
+		if !m.HasOperation(name) {
+			t.Errorf("manifest lacks example operation %s", name)
+		}
+	}
+	for hash, doc := range manifest {
+		if got, ok := m.Lookup(hash); !ok || got != doc {
+			t.Errorf("Lookup(%s) = %q, %v", hash, got, ok)
+		}
+	}
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestExtractIncludesUsedFragments(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"todos.graphql":            "query Todos { todos { ...TodoFields } }",
+		"fragments/todo.gql":       "fragment TodoFields on Todo { id user { ...UserFields } }\nfragment UserFields on User { name }\nfragment Unused on User { id }",
+		"node_modules/x/a.graphql": "query Ignored { a }",
+		".cache/b.graphql":         "query Hidden { b }",
+		"README.md":                "query NotGraphQL { c }",
+	})
+
+	manifest, err := Extract(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest) != 1 {
+		t.Fatalf("extracted %v, want only Todos", manifest)
+	}
+	for hash, doc := range manifest {
+		if Hash(doc) != hash {
+			t.Errorf("key %s is not the hash of its document", hash)
+		}
+		for _, want := range []string{"query Todos", "fragment TodoFields", "fragment UserFields"} {
+			if !strings.Contains(doc, want) {
+				t.Errorf("document lacks %q:\n%s", want, doc)
+			}
+		}
+		if strings.Contains(doc, "Unused") {
+			t.Errorf("document includes an unused fragment:\n%s", doc)
+		}
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"anonymous":          {"a.graphql": "{ todos { id } }"},
+		"duplicate":          {"a.graphql": "query A { a }", "b.graphql": "query A { b }"},
+		"duplicate fragment": {"a.graphql": "fragment F on T { a }", "b.graphql": "fragment F on T { b }"},
+		"unknown fragment":   {"a.graphql": "query A { ...Missing }"},
+		"syntax":             {"a.graphql": "query A {"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if manifest, err := Extract(writeFiles(t, files)); err == nil {
+				t.Errorf("Extract = %v, want an error", manifest)
+			}
+		})
+	}
+}
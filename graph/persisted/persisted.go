@@ -0,0 +1,240 @@
+// Package persisted restricts the server to a trusted allowlist of
+// operations. The allowlist is a manifest mapping the SHA-256 hash of each
+// document to its text, as produced by Extract through the cmd/persisted
+// command. Clients send only the hash, using the same
+// extensions.persistedQuery field as automatic persisted queries.
+package persisted
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/fsnotify/fsnotify"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"go.uber.org/zap"
+)
+
+// Error codes set in extensions.code.
+const (
+	CodeNotFound   = "PERSISTED_QUERY_NOT_FOUND"
+	CodeNotAllowed = "OPERATION_NOT_ALLOWLISTED"
+)
+
+// Manifest is a hot-swappable set of allowlisted documents keyed by hash.
+type Manifest struct {
+	path string
+	ops  atomic.Pointer[operations]
+}
+
+type operations struct {
+	docs  map[string]string // by hash
+	names map[string]bool   // of every operation in docs
+}
+
+// Load reads the manifest at path.
+func Load(path string) (*Manifest, error) {
+	m := &Manifest{path: path}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload re-reads the manifest file. On error the current operations are
+// kept.
+func (m *Manifest) Reload() error {
+	data, err := os.ReadFile(m.path)
+	if err != nil {
+		return fmt.Errorf("read manifest: %w", err)
+	}
+	ops := &operations{names: make(map[string]bool)}
+	if err := json.Unmarshal(data, &ops.docs); err != nil {
+		return fmt.Errorf("parse manifest %s: %w", m.path, err)
+	}
+	for hash, doc := range ops.docs {
+		if Hash(doc) != hash {
+			return fmt.Errorf("manifest %s: hash %s does not match its document", m.path, hash)
+		}
+		query, err := parser.ParseQuery(&ast.Source{Input: doc})
+		if err != nil {
+			return fmt.Errorf("manifest %s: parse document %s: %w", m.path, hash, err)
+		}
+		for _, op := range query.Operations {
+			if op.Name != "" {
+				ops.names[op.Name] = true
+			}
+		}
+	}
+	m.ops.Store(ops)
+	return nil
+}
+
+// Len returns the number of allowlisted operations.
+func (m *Manifest) Len() int {
+	return len(m.ops.Load().docs)
+}
+
+// Lookup returns the document with the given hash.
+func (m *Manifest) Lookup(hash string) (string, bool) {
+	doc, ok := m.ops.Load().docs[hash]
+	return doc, ok
+}
+
+// HasOperation reports whether a document in the manifest defines an
+// operation called name.
+func (m *Manifest) HasOperation(name string) bool {
+	return m.ops.Load().names[name]
+}
+
+// Watch reloads the manifest whenever its content changes until ctx is
+// done. The directory is watched rather than the file, and every event
+// re-resolves the path, so replacements are picked up whether the file is
+// written in place, renamed over, or reached through a symlink that is
+// swapped, as Kubernetes does for ConfigMap volumes through "..data".
+func (m *Manifest) Watch(ctx context.Context, logger *zap.Logger) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(m.path)); err != nil {
+		watcher.Close()
+		return err
+	}
+	current, err := m.version()
+	if err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-watcher.Events:
+				// The file may be missing halfway through a swap; a later
+				// event sees the result.
+				v, err := m.version()
+				if err != nil || v.same(current) {
+					continue
+				}
+				current = v
+				if err := m.Reload(); err != nil {
+					logger.Error("reload persisted operations", zap.Error(err))
+					continue
+				}
+				logger.Info("reloaded persisted operations", zap.Int("operations", m.Len()))
+			case err := <-watcher.Errors:
+				logger.Warn("watch persisted operations", zap.Error(err))
+			}
+		}
+	}()
+	return nil
+}
+
+// fileVersion identifies the file the manifest path resolves to.
+type fileVersion struct {
+	target string
+	info   os.FileInfo
+}
+
+func (m *Manifest) version() (fileVersion, error) {
+	target, err := filepath.EvalSymlinks(m.path)
+	if err != nil {
+		return fileVersion{}, err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return fileVersion{}, err
+	}
+	return fileVersion{target: target, info: info}, nil
+}
+
+func (v fileVersion) same(o fileVersion) bool {
+	return v.target == o.target &&
+		os.SameFile(v.info, o.info) &&
+		v.info.Size() == o.info.Size() &&
+		v.info.ModTime().Equal(o.info.ModTime())
+}
+
+// Hash returns the hex SHA-256 of doc, the manifest key for it.
+func Hash(doc string) string {
+	sum := sha256.Sum256([]byte(doc))
+	return hex.EncodeToString(sum[:])
+}
+
+// Extension is a gqlgen handler extension resolving allowlisted hashes to
+// their documents. In strict mode every other document is rejected;
+// otherwise unknown hashes and documents fall through, so it can be
+// combined with extension.AutomaticPersistedQuery registered after it.
+type Extension struct {
+	Manifest *Manifest
+	Strict   bool
+}
+
+var (
+	_ graphql.HandlerExtension          = Extension{}
+	_ graphql.OperationParameterMutator = Extension{}
+)
+
+func (Extension) ExtensionName() string {
+	return "PersistedOperations"
+}
+
+func (e Extension) Validate(graphql.ExecutableSchema) error {
+	if e.Manifest == nil {
+		return fmt.Errorf("persisted operations: Manifest must not be nil")
+	}
+	return nil
+}
+
+func (e Extension) MutateOperationParameters(ctx context.Context, params *graphql.RawParams) *gqlerror.Error {
+	if hash := requestedHash(params); hash != "" {
+		doc, ok := e.Manifest.Lookup(hash)
+		switch {
+		case ok && params.Query == "":
+			params.Query = doc
+			return nil
+		case ok && params.Query == doc:
+			return nil
+		case e.Strict:
+			return reject(CodeNotFound, "persisted operation not found")
+		default:
+			return nil
+		}
+	}
+
+	if !e.Strict {
+		return nil
+	}
+	if _, ok := e.Manifest.Lookup(Hash(params.Query)); ok {
+		return nil
+	}
+	return reject(CodeNotAllowed, "operation is not in the allowlist")
+}
+
+func requestedHash(params *graphql.RawParams) string {
+	pq, ok := params.Extensions["persistedQuery"].(map[string]any)
+	if !ok {
+		return ""
+	}
+	hash, _ := pq["sha256Hash"].(string)
+	return hash
+}
+
+func reject(code, message string) *gqlerror.Error {
+	err := gqlerror.Errorf("%s", message)
+	errcode.Set(err, code)
+	return err
+}
@@ -0,0 +1,118 @@
+package persisted
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func writeManifest(t *testing.T, path string, docs ...string) {
+	t.Helper()
+	ops := make(map[string]string, len(docs))
+	for _, doc := range docs {
+		ops[Hash(doc)] = doc
+	}
+	data, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitForLen(t *testing.T, m *Manifest, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Len() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("manifest has %d operations, want %d after reload", m.Len(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManifestHasOperation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ops.json")
+	writeManifest(t, path, "query Todos { todos { id } }", "{ me { id } }")
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.HasOperation("Todos") {
+		t.Error("HasOperation(Todos) = false")
+	}
+	if m.HasOperation("Me") || m.HasOperation("") {
+		t.Error("HasOperation reports an operation the manifest does not name")
+	}
+}
+
+func TestWatchReloadsRewrittenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ops.json")
+	writeManifest(t, path, "query A { a }")
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := m.Watch(ctx, zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+
+	writeManifest(t, path, "query A { a }", "query B { b }")
+	waitForLen(t, m, 2)
+}
+
+// TestWatchReloadsConfigMapUpdate swaps the manifest the way the kubelet
+// updates a ConfigMap volume: the file is a symlink through "..data", and an
+// update points "..data" at a new directory without touching the file.
+func TestWatchReloadsConfigMapUpdate(t *testing.T) {
+	dir := t.TempDir()
+	version := func(name string, docs ...string) {
+		if err := os.Mkdir(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeManifest(t, filepath.Join(dir, name, "ops.json"), docs...)
+	}
+	version("..v1", "query A { a }")
+	if err := os.Symlink("..v1", filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "ops.json")
+	if err := os.Symlink(filepath.Join("..data", "ops.json"), path); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := m.Watch(ctx, zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+
+	version("..v2", "query A { a }", "query B { b }")
+	if err := os.Symlink("..v2", filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "..v1")); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForLen(t, m, 2)
+	if !m.HasOperation("B") {
+		t.Error("reloaded manifest lacks operation B")
+	}
+}
@@ -1,7 +1,28 @@
 package graph
 
+import (
+	"github.com/natnael_wondwoesn/GGStarter/graph/cachecontrol"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/storage"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+)
+
 // This file will not be regenerated automatically.
 //
 // It serves as dependency injection for your app, add any dependencies you require here.
 
-type Resolver struct{}
+type Resolver struct {
+	Store  store.Store
+	Events *pubsub.Hub[*model.Todo]
+	Auth   *auth.Service
+	// Responses, if set, is told which types a mutation changed so that
+	// cached responses containing them are dropped.
+	Responses cachecontrol.Invalidator
+	// Files stores attachment content; uploads fail when it is nil.
+	Files storage.Storage
+	// AllowedFileTypes lists the MIME types, or prefixes such as "image/*",
+	// accepted by attachFile.
+	AllowedFileTypes []string
+}
@@ -0,0 +1,154 @@
+// Package scalars implements the custom scalars of the schema. Each scalar
+// is bound in gqlgen.yml through its MarshalX and UnmarshalX functions.
+// Unmarshal functions reject anything but the canonical form with a
+// validation error naming the scalar and the offending value.
+package scalars
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+)
+
+// MarshalDateTime writes t as an RFC 3339 string in UTC.
+func MarshalDateTime(t time.Time) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		io.WriteString(w, strconv.Quote(t.UTC().Format(time.RFC3339Nano)))
+	})
+}
+
+// UnmarshalDateTime accepts an RFC 3339 date-time with a time zone, such
+// as "2025-01-31T09:30:00Z" or "2025-01-31T10:30:00.5+01:00".
+func UnmarshalDateTime(v any) (time.Time, error) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, typeError("DateTime", v)
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, apperr.InvalidValue("DateTime must be an RFC 3339 date-time with a time zone, such as %q; got %q", "2025-01-31T09:30:00Z", s)
+	}
+	return t, nil
+}
+
+// MarshalUUID writes id in its canonical lowercase form.
+func MarshalUUID(id uuid.UUID) graphql.Marshaler {
+	return graphql.MarshalString(id.String())
+}
+
+// UnmarshalUUID accepts a version 4 or 7 UUID in the canonical
+// 8-4-4-4-12 hex form.
+func UnmarshalUUID(v any) (uuid.UUID, error) {
+	s, ok := v.(string)
+	if !ok {
+		return uuid.Nil, typeError("UUID", v)
+	}
+	id, err := uuid.Parse(s)
+	if err != nil || len(s) != 36 {
+		return uuid.Nil, apperr.InvalidValue("UUID must be in the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx; got %q", s)
+	}
+	if id.Variant() != uuid.RFC4122 {
+		return uuid.Nil, apperr.InvalidValue("UUID must use the RFC 9562 variant; got %q", s)
+	}
+	if v := id.Version(); v != 4 && v != 7 {
+		return uuid.Nil, apperr.InvalidValue("UUID must be version 4 or 7; got version %d in %q", v, s)
+	}
+	return id, nil
+}
+
+// MarshalEmail writes the address unchanged.
+func MarshalEmail(s string) graphql.Marshaler {
+	return graphql.MarshalString(s)
+}
+
+// UnmarshalEmail accepts a bare address such as "ada@example.com". Display
+// names, angle brackets and surrounding whitespace are rejected.
+func UnmarshalEmail(v any) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", typeError("Email", v)
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s || addr.Name != "" {
+		return "", apperr.InvalidValue("Email must be an address such as %q; got %q", "ada@example.com", s)
+	}
+	if len(s) > 254 {
+		return "", apperr.InvalidValue("Email must be at most 254 characters; got %d", len(s))
+	}
+	return s, nil
+}
+
+// MarshalURL writes u in its string form.
+func MarshalURL(u url.URL) graphql.Marshaler {
+	return graphql.MarshalString(u.String())
+}
+
+// UnmarshalURL accepts an absolute http or https URL with a host.
+func UnmarshalURL(v any) (url.URL, error) {
+	s, ok := v.(string)
+	if !ok {
+		return url.URL{}, typeError("URL", v)
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return url.URL{}, apperr.InvalidValue("URL is malformed: %q", s)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return url.URL{}, apperr.InvalidValue("URL must be absolute with an http or https scheme; got %q", s)
+	}
+	if u.Host == "" {
+		return url.URL{}, apperr.InvalidValue("URL must have a host; got %q", s)
+	}
+	return *u, nil
+}
+
+// MarshalJSON writes raw as is. An empty value is written as null.
+func MarshalJSON(raw json.RawMessage) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		if len(raw) == 0 {
+			io.WriteString(w, "null")
+			return
+		}
+		w.Write(raw)
+	})
+}
+
+// UnmarshalJSON accepts any value, given either as a variable or as a
+// GraphQL literal.
+func UnmarshalJSON(v any) (json.RawMessage, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, apperr.InvalidValue("JSON value cannot be encoded: %v", err)
+	}
+	return b, nil
+}
+
+// typeError reports a value of the wrong GraphQL type for a string scalar.
+func typeError(scalar string, v any) error {
+	return apperr.InvalidValue("%s must be a string; got %s", scalar, kindOf(v))
+}
+
+func kindOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case int, int32, int64, float32, float64, json.Number:
+		return "a number"
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "an object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,169 @@
+package scalars_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/natnael_wondwoesn/GGStarter/graph"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/graph/scalars"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// literal returns the value gqlgen passes to an unmarshaler for the GraphQL
+// literal lit written inline in a document.
+func literal(t *testing.T, lit string) any {
+	t.Helper()
+	doc, err := parser.ParseQuery(&ast.Source{Input: "{ f(a: " + lit + ") }"})
+	if err != nil {
+		t.Fatalf("parse %s: %v", lit, err)
+	}
+	v, err := doc.Operations[0].SelectionSet[0].(*ast.Field).Arguments[0].Value.Value(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// variable returns the value gqlgen passes to an unmarshaler for the JSON
+// variable value raw.
+func variable(t *testing.T, raw string) any {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+	return v
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	unmarshalers := map[string]func(any) error{
+		"DateTime": func(v any) error { _, err := scalars.UnmarshalDateTime(v); return err },
+		"UUID":     func(v any) error { _, err := scalars.UnmarshalUUID(v); return err },
+		"Email":    func(v any) error { _, err := scalars.UnmarshalEmail(v); return err },
+		"URL":      func(v any) error { _, err := scalars.UnmarshalURL(v); return err },
+	}
+	tests := []struct {
+		name   string
+		scalar string
+		value  string // as JSON, which is also its GraphQL literal
+		want   string // the error message, or empty when valid
+	}{
+		{"DateTime with zone", "DateTime", `"2025-01-31T10:30:00.5+01:00"`, ""},
+		{"DateTime without zone", "DateTime", `"2025-01-31T09:30:00"`,
+			`DateTime must be an RFC 3339 date-time with a time zone, such as "2025-01-31T09:30:00Z"; got "2025-01-31T09:30:00"`},
+		{"date only", "DateTime", `"2025-01-31"`,
+			`DateTime must be an RFC 3339 date-time with a time zone, such as "2025-01-31T09:30:00Z"; got "2025-01-31"`},
+		{"v4 UUID", "UUID", `"0b9b1a5e-8d6b-4c1e-9f0a-3c2d4e5f6a7b"`, ""},
+		{"v7 UUID", "UUID", `"01890a5d-ac96-774b-bcce-b302099a8057"`, ""},
+		{"v1 UUID", "UUID", `"c232ab00-9414-11ec-b3c8-9f6bdeced846"`,
+			`UUID must be version 4 or 7; got version 1 in "c232ab00-9414-11ec-b3c8-9f6bdeced846"`},
+		{"UUID in braces", "UUID", `"{0b9b1a5e-8d6b-4c1e-9f0a-3c2d4e5f6a7b}"`,
+			`UUID must be in the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx; got "{0b9b1a5e-8d6b-4c1e-9f0a-3c2d4e5f6a7b}"`},
+		{"UUID as URN", "UUID", `"urn:uuid:0b9b1a5e-8d6b-4c1e-9f0a-3c2d4e5f6a7b"`,
+			`UUID must be in the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx; got "urn:uuid:0b9b1a5e-8d6b-4c1e-9f0a-3c2d4e5f6a7b"`},
+		{"UUID without hyphens", "UUID", `"0b9b1a5e8d6b4c1e9f0a3c2d4e5f6a7b"`,
+			`UUID must be in the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx; got "0b9b1a5e8d6b4c1e9f0a3c2d4e5f6a7b"`},
+		{"bare email", "Email", `"ada@example.com"`, ""},
+		{"email with display name", "Email", `"Ada <ada@x>"`,
+			`Email must be an address such as "ada@example.com"; got "Ada <ada@x>"`},
+		{"email with spaces", "Email", `" ada@example.com"`,
+			`Email must be an address such as "ada@example.com"; got " ada@example.com"`},
+		{"https URL", "URL", `"https://example.com/a?b=c"`, ""},
+		{"relative URL", "URL", `"/todos/1"`,
+			`URL must be absolute with an http or https scheme; got "/todos/1"`},
+		{"ftp URL", "URL", `"ftp://example.com/file"`,
+			`URL must be absolute with an http or https scheme; got "ftp://example.com/file"`},
+		{"javascript URL", "URL", `"javascript:alert(1)"`,
+			`URL must be absolute with an http or https scheme; got "javascript:alert(1)"`},
+		{"URL without host", "URL", `"https:///path"`, `URL must have a host; got "https:///path"`},
+		{"number", "Email", `42`, "Email must be a string; got a number"},
+		{"list", "DateTime", `["2025-01-31T09:30:00Z"]`, "DateTime must be a string; got a list"},
+	}
+	for _, tt := range tests {
+		for form, value := range map[string]func(*testing.T, string) any{"literal": literal, "variable": variable} {
+			t.Run(tt.name+" as "+form, func(t *testing.T) {
+				err := unmarshalers[tt.scalar](value(t, tt.value))
+				if tt.want == "" {
+					if err != nil {
+						t.Fatalf("rejected: %v", err)
+					}
+					return
+				}
+				var appErr *apperr.Error
+				if !errors.As(err, &appErr) || appErr.Code != apperr.CodeValidation {
+					t.Fatalf("error %v is not a %s", err, apperr.CodeValidation)
+				}
+				if err.Error() != tt.want {
+					t.Errorf("error:\n  %s\nwant:\n  %s", err, tt.want)
+				}
+			})
+		}
+	}
+}
+
+// TestErrorsReachClients runs the scalars of the schema's arguments inline
+// and as variables and checks the client sees the scalar's message at the
+// argument's path.
+func TestErrorsReachClients(t *testing.T) {
+	cfg := graph.Config{Resolvers: &graph.Resolver{Store: store.NewMemory(), Events: pubsub.NewHub[*model.Todo]()}}
+	cfg.Directives.Auth = graph.Auth
+	cfg.Directives.HasRole = graph.HasRole
+	srv := handler.New(graph.NewExecutableSchema(cfg))
+	srv.AddTransport(transport.POST{})
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		want      string
+	}{
+		{"Email literal", `mutation { register(input: {name: "Ada", email: "Ada <ada@x>", password: "correct horse"}) { accessToken } }`, nil,
+			`Email must be an address such as "ada@example.com"; got "Ada <ada@x>"`},
+		{"Email variable", `mutation ($email: Email!) { register(input: {name: "Ada", email: $email, password: "correct horse"}) { accessToken } }`,
+			map[string]any{"email": "Ada <ada@x>"},
+			`Email must be an address such as "ada@example.com"; got "Ada <ada@x>"`},
+		{"DateTime literal", `mutation { createTodo(input: {text: "t", userId: "u", dueAt: "2025-01-31T09:30:00"}) { id } }`, nil,
+			`DateTime must be an RFC 3339 date-time with a time zone`},
+		{"DateTime variable", `mutation ($at: DateTime) { createTodo(input: {text: "t", userId: "u", dueAt: $at}) { id } }`,
+			map[string]any{"at": "2025-01-31T09:30:00"},
+			`DateTime must be an RFC 3339 date-time with a time zone`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(map[string]any{"query": tt.query, "variables": tt.variables})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+
+			var resp struct {
+				Errors []struct {
+					Message string `json:"message"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode %s: %v", rec.Body, err)
+			}
+			if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, tt.want) {
+				t.Errorf("errors %+v, want one containing %q", resp.Errors, tt.want)
+			}
+		})
+	}
+}
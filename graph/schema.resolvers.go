@@ -6,26 +6,272 @@ package graph
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"strings"
 
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
+	"github.com/natnael_wondwoesn/GGStarter/graph/dataloader"
 	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+	"github.com/natnael_wondwoesn/GGStarter/internal/storage"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
 )
 
+// URL is the resolver for the url field.
+func (r *attachmentResolver) URL(ctx context.Context, obj *model.Attachment) (string, error) {
+	return r.Files.URL(ctx, obj.Key)
+}
+
+// Register is the resolver for the register field.
+func (r *mutationResolver) Register(ctx context.Context, input model.RegisterInput) (*model.AuthPayload, error) {
+	session, err := r.Auth.Register(ctx, input.Name, input.Email, input.Password)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidate(ctx, "User")
+	return authPayload(session), nil
+}
+
+// Login is the resolver for the login field.
+func (r *mutationResolver) Login(ctx context.Context, input model.LoginInput) (*model.AuthPayload, error) {
+	session, err := r.Auth.Login(ctx, input.Email, input.Password)
+	if err != nil {
+		return nil, err
+	}
+	return authPayload(session), nil
+}
+
+// RefreshToken is the resolver for the refreshToken field.
+func (r *mutationResolver) RefreshToken(ctx context.Context, token string) (*model.AuthPayload, error) {
+	session, err := r.Auth.Refresh(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return authPayload(session), nil
+}
+
+// Logout is the resolver for the logout field.
+func (r *mutationResolver) Logout(ctx context.Context, refreshToken string) (bool, error) {
+	if err := r.Auth.Logout(ctx, refreshToken); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateUser is the resolver for the createUser field.
+func (r *mutationResolver) CreateUser(ctx context.Context, input model.NewUser) (*model.User, error) {
+	user := &model.User{Name: input.Name, Roles: []model.Role{model.RoleUser}}
+	if err := r.Store.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	r.invalidate(ctx, "User")
+	return user, nil
+}
+
 // CreateTodo is the resolver for the createTodo field.
 func (r *mutationResolver) CreateTodo(ctx context.Context, input model.NewTodo) (*model.Todo, error) {
-	panic(fmt.Errorf("not implemented: CreateTodo - createTodo"))
+	if err := requireOwner(ctx, input.UserID); err != nil {
+		return nil, err
+	}
+	if _, err := r.Store.User(ctx, input.UserID); errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.NotFound("user", input.UserID)
+	} else if err != nil {
+		return nil, err
+	}
+
+	todo := &model.Todo{
+		Text:   input.Text,
+		UserID: input.UserID,
+		DueAt:  input.DueAt,
+	}
+	if err := r.Store.CreateTodo(ctx, todo); err != nil {
+		return nil, err
+	}
+	r.invalidate(ctx, todoTypes...)
+	r.publish(topicTodoCreated, todo)
+	return todo, nil
+}
+
+// UpdateTodo is the resolver for the updateTodo field.
+func (r *mutationResolver) UpdateTodo(ctx context.Context, id string, input model.UpdateTodo) (*model.Todo, error) {
+	todo, err := r.ownedTodo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Text != nil {
+		todo.Text = *input.Text
+	}
+	if input.Done != nil {
+		todo.Done = *input.Done
+	}
+	if dueAt, ok := input.DueAt.ValueOK(); ok {
+		todo.DueAt = dueAt
+	}
+	if err := r.Store.UpdateTodo(ctx, todo); err != nil {
+		return nil, err
+	}
+	r.invalidate(ctx, todoTypes...)
+	r.publish(topicTodoUpdated, todo)
+	return todo, nil
+}
+
+// DeleteTodo is the resolver for the deleteTodo field.
+func (r *mutationResolver) DeleteTodo(ctx context.Context, id string) (*model.Todo, error) {
+	if _, err := r.ownedTodo(ctx, id); err != nil {
+		return nil, err
+	}
+	attachments, err := r.Store.AttachmentsByTodoIDs(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	todo, err := r.Store.DeleteTodo(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.NotFound("todo", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.deleteFiles(ctx, attachments)
+	r.invalidate(ctx, todoTypes...)
+	r.publish(topicTodoDeleted, todo)
+	return todo, nil
+}
+
+// AttachFile is the resolver for the attachFile field.
+func (r *mutationResolver) AttachFile(ctx context.Context, todoID string, file graphql.Upload) (*model.Attachment, error) {
+	todo, err := r.ownedTodo(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if r.Files == nil {
+		return nil, apperr.Internal(errors.New("file storage is not configured"))
+	}
+
+	contentType, content, err := storage.Sniff(file.File, r.AllowedFileTypes)
+	if errors.Is(err, storage.ErrTypeNotAllowed) {
+		return nil, apperr.Invalid("file", "file type is not allowed; accepted types are %s", strings.Join(r.AllowedFileTypes, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &model.Attachment{
+		ID:          uuid.NewString(),
+		TodoID:      todo.ID,
+		Filename:    file.Filename,
+		ContentType: contentType,
+		Size:        int32(file.Size), // uploads.max_size is at most math.MaxInt32
+	}
+	attachment.Key = attachmentKey(attachment)
+	if err := r.Files.Put(ctx, attachment.Key, content, file.Size, contentType); err != nil {
+		return nil, err
+	}
+	if err := r.Store.CreateAttachment(ctx, attachment); err != nil {
+		_ = r.Files.Delete(ctx, attachment.Key)
+		return nil, err
+	}
+	r.invalidate(ctx, todoTypes...)
+	return attachment, nil
 }
 
 // Todos is the resolver for the todos field.
 func (r *queryResolver) Todos(ctx context.Context) ([]*model.Todo, error) {
-	panic(fmt.Errorf("not implemented: Todos - todos"))
+	return r.Store.Todos(ctx)
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
+	user, err := r.loadUser(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	return user, err
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
+	users, err := r.Store.Users(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if loaders := dataloader.For(ctx); loaders != nil {
+		for _, u := range users {
+			loaders.UserByID.Prime(u.ID, u)
+		}
+	}
+	return users, nil
+}
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*model.User, error) {
+	claims := auth.ForContext(ctx)
+	if claims == nil {
+		return nil, nil
+	}
+	user, err := r.loadUser(ctx, claims.UserID())
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	return user, err
+}
+
+// TodoCreated is the resolver for the todoCreated field.
+func (r *subscriptionResolver) TodoCreated(ctx context.Context) (<-chan *model.Todo, error) {
+	return r.subscribeTodos(ctx, topicTodoCreated, nil)
+}
+
+// TodoUpdated is the resolver for the todoUpdated field.
+func (r *subscriptionResolver) TodoUpdated(ctx context.Context, userID *string) (<-chan *model.Todo, error) {
+	return r.subscribeTodos(ctx, topicTodoUpdated, userID)
+}
+
+// TodoDeleted is the resolver for the todoDeleted field.
+func (r *subscriptionResolver) TodoDeleted(ctx context.Context) (<-chan *model.Todo, error) {
+	return r.subscribeTodos(ctx, topicTodoDeleted, nil)
 }
 
+// User is the resolver for the user field.
+func (r *todoResolver) User(ctx context.Context, obj *model.Todo) (*model.User, error) {
+	return r.loadUser(ctx, obj.UserID)
+}
+
+// Attachments is the resolver for the attachments field.
+func (r *todoResolver) Attachments(ctx context.Context, obj *model.Todo) ([]*model.Attachment, error) {
+	return r.loadAttachments(ctx, obj.ID)
+}
+
+// Todos is the resolver for the todos field.
+func (r *userResolver) Todos(ctx context.Context, obj *model.User, first *int32, after *string) (*model.TodoConnection, error) {
+	todos, err := r.loadTodosByUser(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	return paginateTodos(todos, first, after)
+}
+
+// Attachment returns AttachmentResolver implementation.
+func (r *Resolver) Attachment() AttachmentResolver { return &attachmentResolver{r} }
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+// Todo returns TodoResolver implementation.
+func (r *Resolver) Todo() TodoResolver { return &todoResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type attachmentResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type todoResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
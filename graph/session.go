@@ -0,0 +1,17 @@
+package graph
+
+import (
+	"time"
+
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+)
+
+func authPayload(s *auth.Session) *model.AuthPayload {
+	return &model.AuthPayload{
+		AccessToken:  s.AccessToken,
+		ExpiresIn:    int32(time.Until(s.AccessTokenExpiresAt).Round(time.Second).Seconds()),
+		RefreshToken: s.RefreshToken,
+		User:         s.User,
+	}
+}
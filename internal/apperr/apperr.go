@@ -0,0 +1,106 @@
+// Package apperr defines the typed errors returned by resolvers and services
+// and presents them to GraphQL clients with a stable extensions.code.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code is the machine-readable error class reported in extensions.code.
+type Code string
+
+const (
+	CodeNotFound        Code = "NOT_FOUND"
+	CodeValidation      Code = "VALIDATION_FAILED"
+	CodeConflict        Code = "CONFLICT"
+	CodeUnauthenticated Code = "UNAUTHENTICATED"
+	CodeForbidden       Code = "FORBIDDEN"
+	CodeInternal        Code = "INTERNAL_SERVER_ERROR"
+)
+
+// Error is an error with a code that is safe to show to clients. Err, if
+// set, is the underlying cause and is never shown in production.
+type Error struct {
+	Code    Code
+	Message string
+	// Fields lists the offending inputs of a validation error.
+	Fields []FieldError
+	Err    error
+
+	logged bool // already logged with more context, e.g. a panic's stack
+}
+
+// FieldError describes one invalid input. Path is the dotted path of the
+// field relative to the operation's arguments, such as "input.email".
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns the code of the first *Error in err's chain, or
+// CodeInternal if there is none.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return CodeInternal
+}
+
+// NotFound reports that the resource of the given kind and id does not exist.
+func NotFound(kind, id string) *Error {
+	return &Error{Code: CodeNotFound, Message: fmt.Sprintf("%s %q not found", kind, id)}
+}
+
+// Validation reports one or more invalid inputs.
+func Validation(fields ...FieldError) *Error {
+	msg := "invalid input"
+	if len(fields) == 1 {
+		msg = fields[0].Path + ": " + fields[0].Message
+	}
+	return &Error{Code: CodeValidation, Message: msg, Fields: fields}
+}
+
+// Invalid is shorthand for a validation error on a single field.
+func Invalid(path, format string, args ...any) *Error {
+	return Validation(FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// InvalidValue reports an input value rejected where its location is not
+// known, such as while parsing a scalar. The GraphQL path of the error
+// locates it instead.
+func InvalidValue(format string, args ...any) *Error {
+	return &Error{Code: CodeValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+// Conflict reports that the request conflicts with existing state.
+func Conflict(message string) *Error {
+	return &Error{Code: CodeConflict, Message: message}
+}
+
+// Unauthenticated reports missing or invalid credentials.
+func Unauthenticated(message string) *Error {
+	return &Error{Code: CodeUnauthenticated, Message: message}
+}
+
+// Forbidden reports that the caller may not perform the operation.
+func Forbidden(message string) *Error {
+	return &Error{Code: CodeForbidden, Message: message}
+}
+
+// Internal wraps an unexpected failure. Its details are masked in production.
+func Internal(err error) *Error {
+	return &Error{Code: CodeInternal, Message: "internal server error", Err: err}
+}
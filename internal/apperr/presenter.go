@@ -0,0 +1,81 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/natnael_wondwoesn/GGStarter/internal/requestid"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.uber.org/zap"
+)
+
+// Presenter returns a graphql.ErrorPresenterFunc that sets extensions.code
+// from typed errors. Errors that already carry a code, such as gqlgen's
+// parse and validation errors, are passed through. Anything else is an
+// internal error: it is logged and, when mask is true, its message is
+// replaced so that implementation details never reach the client.
+func Presenter(logger *zap.Logger, mask bool) graphql.ErrorPresenterFunc {
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+		var appErr *Error
+		if errors.As(err, &appErr) && appErr.Code != CodeInternal {
+			// Copy so that a shared sentinel error is never mutated.
+			presented := *gqlErr
+			presented.Message = appErr.Message
+			presented.Extensions = withCode(gqlErr.Extensions, appErr.Code)
+			if len(appErr.Fields) > 0 {
+				presented.Extensions["fields"] = appErr.Fields
+			}
+			return &presented
+		}
+		if appErr == nil {
+			if _, ok := gqlErr.Extensions["code"]; ok {
+				return gqlErr
+			}
+		}
+
+		if appErr == nil || !appErr.logged {
+			logger.Error("internal error",
+				zap.String("request_id", requestid.FromContext(ctx)),
+				zap.String("path", gqlErr.Path.String()),
+				zap.Error(err),
+			)
+		}
+		presented := *gqlErr
+		presented.Extensions = withCode(gqlErr.Extensions, CodeInternal)
+		if mask {
+			presented.Message = "internal server error"
+		}
+		return &presented
+	}
+}
+
+// Recover returns a graphql.RecoverFunc that logs a resolver panic with its
+// stack trace and reports it to the client as an internal error.
+func Recover(logger *zap.Logger) graphql.RecoverFunc {
+	return func(ctx context.Context, p any) error {
+		err, ok := p.(error)
+		if !ok {
+			err = fmt.Errorf("%v", p)
+		}
+		logger.Error("resolver panic",
+			zap.String("request_id", requestid.FromContext(ctx)),
+			zap.Any("panic", p),
+			zap.ByteString("stack", debug.Stack()),
+		)
+		return &Error{Code: CodeInternal, Message: "panic", Err: err, logged: true}
+	}
+}
+
+func withCode(ext map[string]any, code Code) map[string]any {
+	out := make(map[string]any, len(ext)+1)
+	for k, v := range ext {
+		out[k] = v
+	}
+	out["code"] = string(code)
+	return out
+}
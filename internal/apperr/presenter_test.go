@@ -0,0 +1,116 @@
+package apperr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/natnael_wondwoesn/GGStarter/graph"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/requestid"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// brokenStore fails to list todos and panics when loading a user.
+type brokenStore struct {
+	*store.Memory
+}
+
+func (brokenStore) Todos(context.Context) ([]*model.Todo, error) {
+	return nil, errors.New("dial tcp 10.0.0.5:5432: connection refused")
+}
+
+func (brokenStore) User(context.Context, string) (*model.User, error) {
+	panic("nil map in user cache")
+}
+
+type response struct {
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+// run executes query with the presenter and recover function configured as
+// in production when mask is true, returning the response and log entries.
+func run(t *testing.T, mask bool, query string) (response, *observer.ObservedLogs) {
+	t.Helper()
+	core, logs := observer.New(zapcore.ErrorLevel)
+	logger := zap.New(core)
+
+	cfg := graph.Config{Resolvers: &graph.Resolver{Store: brokenStore{store.NewMemory()}, Events: pubsub.NewHub[*model.Todo]()}}
+	srv := handler.New(graph.NewExecutableSchema(cfg))
+	srv.AddTransport(transport.GET{})
+	srv.SetErrorPresenter(apperr.Presenter(logger, mask))
+	srv.SetRecoverFunc(apperr.Recover(logger))
+	h := requestid.Middleware("")(srv)
+
+	req := httptest.NewRequest(http.MethodGet, "/query?query="+query, nil)
+	req.Header.Set(requestid.DefaultHeader, "req-123")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	if len(resp.Errors) != 1 {
+		t.Fatalf("got errors %+v, want one", resp.Errors)
+	}
+	if code := resp.Errors[0].Extensions["code"]; code != string(apperr.CodeInternal) {
+		t.Errorf("code = %v, want %s", code, apperr.CodeInternal)
+	}
+	return resp, logs
+}
+
+func TestPresenter(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		log     string
+		details string // the underlying error, hidden in production
+	}{
+		{"error", "{todos{id}}", "internal error", "connection refused"},
+		{"panic", `{user(id:"1"){id}}`, "resolver panic", "nil map in user cache"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" in production", func(t *testing.T) {
+			resp, logs := run(t, true, tt.query)
+			if msg := resp.Errors[0].Message; msg != "internal server error" {
+				t.Errorf("message = %q, want the generic message", msg)
+			}
+			if body, _ := json.Marshal(resp); strings.Contains(string(body), tt.details) {
+				t.Errorf("response %s leaks %q", body, tt.details)
+			}
+
+			entries := logs.All()
+			if len(entries) != 1 || entries[0].Message != tt.log {
+				t.Fatalf("logged %+v, want one %q entry", entries, tt.log)
+			}
+			fields := entries[0].ContextMap()
+			if fields["request_id"] != "req-123" {
+				t.Errorf("log request_id = %v, want req-123", fields["request_id"])
+			}
+			if logged, _ := json.Marshal(fields); !strings.Contains(string(logged), tt.details) {
+				t.Errorf("log %s lacks the underlying error %q", logged, tt.details)
+			}
+		})
+		t.Run(tt.name+" in development", func(t *testing.T) {
+			resp, _ := run(t, false, tt.query)
+			if msg := resp.Errors[0].Message; !strings.Contains(msg, tt.details) {
+				t.Errorf("message = %q, want the details %q", msg, tt.details)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// Package auth authenticates callers from JWT bearer tokens and carries the
+// resulting claims through request contexts.
+package auth
+
+import (
+	"context"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type ctxKey struct{}
+
+// Claims are the JWT claims issued to an authenticated user. The user ID is
+// carried in the standard subject claim.
+type Claims struct {
+	Roles []string `json:"roles,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the ID of the authenticated user.
+func (c *Claims) UserID() string {
+	return c.Subject
+}
+
+// WithClaims returns a copy of ctx carrying claims.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, ctxKey{}, claims)
+}
+
+// ForContext returns the claims of the authenticated caller, or nil for
+// anonymous requests.
+func ForContext(ctx context.Context) *Claims {
+	claims, _ := ctx.Value(ctxKey{}).(*Claims)
+	return claims
+}
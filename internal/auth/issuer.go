@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/natnael_wondwoesn/GGStarter/config"
+)
+
+// Issuer signs HS256 access tokens that Verifier accepts.
+type Issuer struct {
+	secret   []byte
+	issuer   string
+	audience string
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+// NewIssuer returns an Issuer whose tokens expire after cfg.Expiration hours.
+func NewIssuer(cfg config.JWTConfig) *Issuer {
+	return &Issuer{
+		secret:   []byte(cfg.Secret),
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		ttl:      time.Duration(cfg.Expiration) * time.Hour,
+		now:      time.Now,
+	}
+}
+
+// Issue returns a signed access token for userID and its expiry time.
+func (i *Issuer) Issue(userID string, roles []string) (string, time.Time, error) {
+	now := i.now()
+	expiresAt := now.Add(i.ttl)
+
+	claims := &Claims{
+		Roles: roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID,
+			Issuer:    i.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	if i.audience != "" {
+		claims.Audience = jwt.ClaimStrings{i.audience}
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/natnael_wondwoesn/GGStarter/config"
+)
+
+const testSecret = "test-secret-that-is-long-enough-for-hs256"
+
+// writeJWKS writes a JSON Web Key Set holding key under kid and returns its
+// path.
+func writeJWKS(t *testing.T, kid string, key *rsa.PublicKey) string {
+	t.Helper()
+	set := map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.JWTConfig{
+		Secret:     testSecret,
+		Expiration: 1,
+		Issuer:     "ggstarter",
+		Audience:   "api",
+		JWKSFile:   writeJWKS(t, "key-1", &rsaKey.PublicKey),
+	}
+	v, err := NewVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hsOnly, err := NewVerifier(config.JWTConfig{Secret: testSecret, Issuer: "ggstarter", Audience: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: must(x509.MarshalPKIXPublicKey(&rsaKey.PublicKey))})
+
+	now := time.Now()
+	claims := func(modify func(*Claims)) *Claims {
+		c := &Claims{RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user-1",
+			Issuer:    "ggstarter",
+			Audience:  jwt.ClaimStrings{"api"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		}}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+	sign := func(method jwt.SigningMethod, kid string, key any, c *Claims) string {
+		token := jwt.NewWithClaims(method, c)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	issued, _, err := NewIssuer(cfg).Issue("user-1", []string{"USER"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		token    string
+		valid    bool
+	}{
+		{"issued HS256", v, issued, true},
+		{"HS256", v, sign(jwt.SigningMethodHS256, "", []byte(testSecret), claims(nil)), true},
+		{"RS256 with known kid", v, sign(jwt.SigningMethodRS256, "key-1", rsaKey, claims(nil)), true},
+		{"RS256 with unknown kid", v, sign(jwt.SigningMethodRS256, "key-2", rsaKey, claims(nil)), false},
+		{"RS256 without a JWKS", hsOnly, sign(jwt.SigningMethodRS256, "key-1", rsaKey, claims(nil)), false},
+		{"HS256 signed with the RSA public key", v, sign(jwt.SigningMethodHS256, "key-1", publicPEM, claims(nil)), false},
+		{"alg none", v, sign(jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, claims(nil)), false},
+		{"HS256 with the wrong secret", v, sign(jwt.SigningMethodHS256, "", []byte("another-secret"), claims(nil)), false},
+		{"expired", v, sign(jwt.SigningMethodHS256, "", []byte(testSecret), claims(func(c *Claims) {
+			c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+		})), false},
+		{"no expiry", v, sign(jwt.SigningMethodHS256, "", []byte(testSecret), claims(func(c *Claims) {
+			c.ExpiresAt = nil
+		})), false},
+		{"wrong issuer", v, sign(jwt.SigningMethodHS256, "", []byte(testSecret), claims(func(c *Claims) {
+			c.Issuer = "someone-else"
+		})), false},
+		{"wrong audience", v, sign(jwt.SigningMethodHS256, "", []byte(testSecret), claims(func(c *Claims) {
+			c.Audience = jwt.ClaimStrings{"other"}
+		})), false},
+		{"missing subject", v, sign(jwt.SigningMethodHS256, "", []byte(testSecret), claims(func(c *Claims) {
+			c.Subject = ""
+		})), false},
+		{"malformed", v, "not.a.jwt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.verifier.Verify(tt.token)
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				if got.UserID() != "user-1" {
+					t.Errorf("UserID = %q, want user-1", got.UserID())
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Verify accepted the token")
+			}
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify error %v does not wrap ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestNewVerifierRejectsBadJWKS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, []byte(`{"keys":[{"kty":"EC","kid":"k"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewVerifier(config.JWTConfig{Secret: testSecret, JWKSFile: path}); err == nil {
+		t.Error("a JWKS without RSA keys was accepted")
+	}
+	if _, err := NewVerifier(config.JWTConfig{Secret: testSecret, JWKSFile: path + ".missing"}); err == nil {
+		t.Error("a missing JWKS file was accepted")
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+)
+
+// Middleware authenticates requests carrying an "Authorization: Bearer"
+// header and stores the claims on the request context. Requests without the
+// header continue anonymously; requests with an invalid token are rejected.
+func Middleware(v *Verifier, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := v.authenticate(header)
+		if err != nil {
+			writeUnauthenticated(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+	})
+}
+
+// WebsocketInit authenticates the Authorization value of a WebSocket
+// connection_init payload the same way Middleware treats the HTTP header.
+// Connections without credentials stay anonymous.
+func WebsocketInit(v *Verifier) transport.WebsocketInitFunc {
+	return func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		header := payload.Authorization()
+		if header == "" {
+			return ctx, &payload, nil
+		}
+		claims, err := v.authenticate(header)
+		if err != nil {
+			return ctx, nil, err
+		}
+		return WithClaims(ctx, claims), &payload, nil
+	}
+}
+
+func (v *Verifier) authenticate(header string) (*Claims, error) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, errors.New("auth: authorization must use the Bearer scheme")
+	}
+	return v.Verify(strings.TrimSpace(token))
+}
+
+func writeUnauthenticated(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": []map[string]any{{
+			"message":    err.Error(),
+			"extensions": map[string]any{"code": apperr.CodeUnauthenticated},
+		}},
+	})
+}
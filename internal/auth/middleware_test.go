@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/natnael_wondwoesn/GGStarter/config"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+)
+
+func newTestVerifier(t *testing.T) (*Verifier, string) {
+	t.Helper()
+	cfg := config.JWTConfig{Secret: testSecret, Expiration: 1}
+	v, err := NewVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := NewIssuer(cfg).Issue("user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v, token
+}
+
+func TestMiddleware(t *testing.T) {
+	v, token := newTestVerifier(t)
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+		userID string // of the claims reaching the handler
+	}{
+		{"anonymous", "", http.StatusOK, ""},
+		{"valid bearer", "Bearer " + token, http.StatusOK, "user-1"},
+		{"lowercase scheme", "bearer " + token, http.StatusOK, "user-1"},
+		{"wrong scheme", "Basic " + token, http.StatusUnauthorized, ""},
+		{"missing token", "Bearer ", http.StatusUnauthorized, ""},
+		{"no scheme", token, http.StatusUnauthorized, ""},
+		{"invalid token", "Bearer not.a.jwt", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID string
+			h := Middleware(v, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if claims := ForContext(r.Context()); claims != nil {
+					userID = claims.UserID()
+				}
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/query", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if userID != tt.userID {
+				t.Errorf("user ID = %q, want %q", userID, tt.userID)
+			}
+			if tt.status != http.StatusUnauthorized {
+				return
+			}
+			var body struct {
+				Errors []struct {
+					Extensions struct {
+						Code string `json:"code"`
+					} `json:"extensions"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode %s: %v", rec.Body, err)
+			}
+			if len(body.Errors) != 1 || body.Errors[0].Extensions.Code != string(apperr.CodeUnauthenticated) {
+				t.Errorf("body %s lacks a single %s error", rec.Body, apperr.CodeUnauthenticated)
+			}
+			if rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestWebsocketInit(t *testing.T) {
+	v, token := newTestVerifier(t)
+	init := WebsocketInit(v)
+
+	tests := []struct {
+		name    string
+		payload transport.InitPayload
+		userID  string
+		wantErr bool
+	}{
+		{"anonymous", transport.InitPayload{}, "", false},
+		{"valid token", transport.InitPayload{"Authorization": "Bearer " + token}, "user-1", false},
+		{"lowercase key", transport.InitPayload{"authorization": "Bearer " + token}, "user-1", false},
+		{"invalid token", transport.InitPayload{"Authorization": "Bearer not.a.jwt"}, "", true},
+		{"wrong scheme", transport.InitPayload{"Authorization": token}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, _, err := init(context.Background(), tt.payload)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("connection_init accepted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("connection_init: %v", err)
+			}
+			var userID string
+			if claims := ForContext(ctx); claims != nil {
+				userID = claims.UserID()
+			}
+			if userID != tt.userID {
+				t.Errorf("user ID = %q, want %q", userID, tt.userID)
+			}
+		})
+	}
+}
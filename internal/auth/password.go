@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/natnael_wondwoesn/GGStarter/config"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLength is the shortest password Register accepts. It is
+// defined by config, which checks the bootstrap admin's password against it.
+const MinPasswordLength = config.MinPasswordLength
+
+// dummyHash is compared against when a login names an unknown email so that
+// the response time does not reveal which emails are registered.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
+// HashPassword returns the bcrypt hash of password.
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", apperr.Invalid("input.password", "must be at least %d characters", MinPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return "", apperr.Invalid("input.password", "must be at most 72 bytes")
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches hash. An empty hash is
+// treated as a mismatch after doing the same amount of work.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
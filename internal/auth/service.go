@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/natnael_wondwoesn/GGStarter/config"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+)
+
+var (
+	ErrInvalidCredentials  = apperr.Unauthenticated("invalid email or password")
+	ErrEmailTaken          = apperr.Conflict("email already registered")
+	ErrInvalidRefreshToken = apperr.Unauthenticated("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token
+	// is presented again. The whole token family is revoked in response.
+	ErrRefreshTokenReused = apperr.Unauthenticated("refresh token reused, session revoked")
+)
+
+// Session is the result of a successful register, login or refresh.
+type Session struct {
+	User                 *model.User
+	AccessToken          string
+	AccessTokenExpiresAt time.Time
+	RefreshToken         string
+}
+
+// Service registers users and manages their access and refresh tokens.
+type Service struct {
+	users      store.UserStore
+	tokens     store.RefreshTokenStore
+	issuer     *Issuer
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+// NewService returns a Service persisting to s and signing tokens per cfg.
+func NewService(s store.Store, cfg config.JWTConfig) *Service {
+	return &Service{
+		users:      s,
+		tokens:     s,
+		issuer:     NewIssuer(cfg),
+		refreshTTL: time.Duration(cfg.RefreshExpiration) * time.Hour,
+		now:        time.Now,
+	}
+}
+
+// Register creates a user with a bcrypt-hashed password and starts a session.
+func (s *Service) Register(ctx context.Context, name, email, password string) (*Session, error) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != strings.TrimSpace(email) {
+		return nil, apperr.Invalid("input.email", "invalid email address %q", email)
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Name:         name,
+		Email:        strings.ToLower(addr.Address),
+		PasswordHash: hash,
+		Roles:        []model.Role{model.RoleUser},
+	}
+	if err := s.users.CreateUser(ctx, user); errors.Is(err, store.ErrConflict) {
+		return nil, ErrEmailTaken
+	} else if err != nil {
+		return nil, err
+	}
+	return s.startSession(ctx, user, uuid.NewString(), uuid.NewString())
+}
+
+// EnsureAdmin creates an admin with the given credentials unless a user
+// with email already exists, in which case that user is returned unchanged.
+// It bootstraps the first admin, who can then create other users.
+func (s *Service) EnsureAdmin(ctx context.Context, name, email, password string) (user *model.User, created bool, err error) {
+	user, err = s.users.UserByEmail(ctx, email)
+	if err == nil {
+		return user, false, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return nil, false, err
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, false, err
+	}
+	user = &model.User{
+		Name:         name,
+		Email:        strings.ToLower(email),
+		PasswordHash: hash,
+		Roles:        []model.Role{model.RoleAdmin},
+	}
+	if err := s.users.CreateUser(ctx, user); err != nil {
+		return nil, false, err
+	}
+	return user, true, nil
+}
+
+// Login checks credentials and starts a new session.
+func (s *Service) Login(ctx context.Context, email, password string) (*Session, error) {
+	user, err := s.users.UserByEmail(ctx, strings.TrimSpace(email))
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+
+	var hash string
+	if user != nil {
+		hash = user.PasswordHash
+	}
+	if !CheckPassword(hash, password) {
+		return nil, ErrInvalidCredentials
+	}
+	return s.startSession(ctx, user, uuid.NewString(), uuid.NewString())
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token in the same family. The presented token is revoked.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*Session, error) {
+	current, err := s.tokens.RefreshTokenByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if current.RevokedAt != nil {
+		// Only a token that was rotated signals reuse; one revoked by
+		// logout or with its family is merely invalid.
+		if current.ReplacedBy != "" {
+			return nil, s.revokeFamily(ctx, current.FamilyID, now)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if !now.Before(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.users.User(ctx, current.UserID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The successor is stored before the presented token is revoked, so a
+	// concurrent reuse that revokes the family cannot miss it.
+	nextID := uuid.NewString()
+	session, err := s.startSession(ctx, user, current.FamilyID, nextID)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := s.tokens.RevokeRefreshToken(ctx, current.ID, nextID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		// A concurrent refresh won the race with the same token.
+		return nil, s.revokeFamily(ctx, current.FamilyID, now)
+	}
+	return session, nil
+}
+
+// Logout revokes the family of refreshToken. Unknown tokens are ignored so
+// that logging out twice is not an error.
+func (s *Service) Logout(ctx context.Context, refreshToken string) error {
+	current, err := s.tokens.RefreshTokenByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.tokens.RevokeRefreshTokenFamily(ctx, current.FamilyID, s.now())
+}
+
+func (s *Service) revokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	if err := s.tokens.RevokeRefreshTokenFamily(ctx, familyID, at); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+func (s *Service) startSession(ctx context.Context, user *model.User, familyID, tokenID string) (*Session, error) {
+	roles := make([]string, len(user.Roles))
+	for i, role := range user.Roles {
+		roles[i] = string(role)
+	}
+	access, expiresAt, err := s.issuer.Issue(user.ID, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := s.now()
+	err = s.tokens.CreateRefreshToken(ctx, &store.RefreshToken{
+		ID:        tokenID,
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: now.Add(s.refreshTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		User:                 user,
+		AccessToken:          access,
+		AccessTokenExpiresAt: expiresAt,
+		RefreshToken:         refresh,
+	}, nil
+}
+
+// hashToken returns the SHA-256 of a refresh token. Refresh tokens carry 256
+// bits of entropy, so a fast hash is sufficient and keeps lookups indexable.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
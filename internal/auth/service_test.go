@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/natnael_wondwoesn/GGStarter/config"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+)
+
+// newTestService returns a Service over an in-memory store whose clock is
+// read from *now.
+func newTestService(t *testing.T, now *time.Time) *Service {
+	t.Helper()
+	s := NewService(store.NewMemory(), config.JWTConfig{
+		Secret:            testSecret,
+		Expiration:        1,
+		RefreshExpiration: 24,
+	})
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func register(t *testing.T, s *Service) *Session {
+	t.Helper()
+	session, err := s.Register(context.Background(), "Ada", "ada@example.com", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	now := time.Now()
+	s := newTestService(t, &now)
+	ctx := context.Background()
+	first := register(t, s)
+
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("refresh returned the presented token")
+	}
+	if second.AccessToken == "" || second.User.ID != first.User.ID {
+		t.Errorf("refresh returned session %+v", second)
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken); err != nil {
+		t.Errorf("rotated token: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	now := time.Now()
+	s := newTestService(t, &now)
+	ctx := context.Background()
+	first := register(t, s)
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := s.Login(ctx, "ada@example.com", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reused token: got %v, want %v", err, ErrRefreshTokenReused)
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("successor of a reused token: got %v, want %v", err, ErrInvalidRefreshToken)
+	}
+	if _, err := s.Refresh(ctx, other.RefreshToken); err != nil {
+		t.Errorf("token of another login: %v", err)
+	}
+}
+
+func TestRefreshRejectsExpiredToken(t *testing.T) {
+	now := time.Now()
+	s := newTestService(t, &now)
+	first := register(t, s)
+
+	now = now.Add(24 * time.Hour)
+	if _, err := s.Refresh(context.Background(), first.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("expired token: got %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestRefreshRejectsUnknownToken(t *testing.T) {
+	now := time.Now()
+	s := newTestService(t, &now)
+	if _, err := s.Refresh(context.Background(), "unknown"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("unknown token: got %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestConcurrentRefreshHasOneWinner(t *testing.T) {
+	now := time.Now()
+	s := newTestService(t, &now)
+	ctx := context.Background()
+	first := register(t, s)
+
+	const n = 8
+	var wg sync.WaitGroup
+	sessions := make([]*Session, n)
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sessions[i], errs[i] = s.Refresh(ctx, first.RefreshToken)
+		}()
+	}
+	wg.Wait()
+
+	var winner *Session
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			if winner != nil {
+				t.Fatal("more than one concurrent refresh succeeded")
+			}
+			winner = sessions[i]
+		case !errors.Is(err, ErrRefreshTokenReused):
+			t.Errorf("losing refresh: got %v, want %v", err, ErrRefreshTokenReused)
+		}
+	}
+	if winner == nil {
+		t.Fatal("no concurrent refresh succeeded")
+	}
+	// The losers saw reuse, which revokes the winner's token too.
+	if _, err := s.Refresh(ctx, winner.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("winner's token after reuse: got %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestLogoutRevokesFamily(t *testing.T) {
+	now := time.Now()
+	s := newTestService(t, &now)
+	ctx := context.Background()
+	first := register(t, s)
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Logout(ctx, second.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("logged out token: got %v, want %v", err, ErrInvalidRefreshToken)
+	}
+	if err := s.Logout(ctx, second.RefreshToken); err != nil {
+		t.Errorf("second logout: %v", err)
+	}
+	if err := s.Logout(ctx, "unknown"); err != nil {
+		t.Errorf("logout of unknown token: %v", err)
+	}
+}
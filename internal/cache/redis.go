@@ -0,0 +1,93 @@
+// Package cache provides graphql.Cache implementations shared between
+// server replicas.
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// add stores a value and records it in an index sorted by insertion time,
+// then evicts the oldest entries beyond the size cap and index entries whose
+// values have expired.
+var add = redis.NewScript(`
+local index = KEYS[1]
+local key = KEYS[2]
+local now = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+local size = tonumber(ARGV[4])
+
+if ttl > 0 then
+	redis.call("SET", key, ARGV[1], "PX", ttl)
+	redis.call("ZREMRANGEBYSCORE", index, "-inf", now - ttl)
+else
+	redis.call("SET", key, ARGV[1])
+end
+redis.call("ZADD", index, now, key)
+
+if size > 0 then
+	local excess = redis.call("ZCARD", index) - size
+	if excess > 0 then
+		local evicted = redis.call("ZRANGE", index, 0, excess - 1)
+		redis.call("ZREMRANGEBYRANK", index, 0, excess - 1)
+		redis.call("DEL", unpack(evicted))
+	end
+end
+return 0
+`)
+
+// Redis is a graphql.Cache[string] stored in Redis, suitable for the
+// automatic persisted query cache. Entries expire after TTL and the oldest
+// are evicted once more than Size are stored; zero disables either bound.
+// Redis errors are logged and treated as misses so that the cache never
+// fails a request.
+//
+// Every key of one cache carries the prefix as a hash tag, "{apq:}index"
+// and "{apq:}<key>", so that on Redis Cluster they share a slot and the
+// eviction script may delete entries it was not passed in KEYS. The whole
+// cache therefore lives on one shard.
+type Redis struct {
+	client redis.UniversalClient
+	prefix string // hash tag wrapping the configured prefix
+	ttl    time.Duration
+	size   int
+	logger *zap.Logger
+}
+
+var _ graphql.Cache[string] = (*Redis)(nil)
+
+// NewRedis returns a cache storing entries under keys starting with
+// "{prefix}". The prefix must not be empty, which Redis Cluster would not
+// treat as a hash tag.
+func NewRedis(client redis.UniversalClient, prefix string, ttl time.Duration, size int, logger *zap.Logger) *Redis {
+	return &Redis{client: client, prefix: "{" + prefix + "}", ttl: ttl, size: size, logger: logger}
+}
+
+func (c *Redis) Get(ctx context.Context, key string) (string, bool) {
+	value, err := c.client.Get(ctx, c.prefix+key).Result()
+	if err == redis.Nil {
+		return "", false
+	}
+	if err != nil {
+		c.logger.Warn("redis cache get", zap.String("prefix", c.prefix), zap.Error(err))
+		return "", false
+	}
+	return value, true
+}
+
+func (c *Redis) Add(ctx context.Context, key string, value string) {
+	err := add.Run(ctx, c.client, []string{c.prefix + "index", c.prefix + key},
+		value,
+		strconv.FormatInt(time.Now().UnixMilli(), 10),
+		c.ttl.Milliseconds(),
+		c.size,
+	).Err()
+	if err != nil {
+		c.logger.Warn("redis cache add", zap.String("prefix", c.prefix), zap.Error(err))
+	}
+}
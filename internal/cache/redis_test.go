@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// newRedis returns a cache backed by an in-process Redis stand-in.
+func newRedis(t *testing.T, ttl time.Duration, size int) (*Redis, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedis(client, "apq:", ttl, size, zap.NewNop()), mr
+}
+
+func TestRedisGetAdd(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newRedis(t, 0, 0)
+
+	if _, ok := c.Get(ctx, "h1"); ok {
+		t.Fatal("hit on an empty cache")
+	}
+	c.Add(ctx, "h1", "{ me { id } }")
+	if got, ok := c.Get(ctx, "h1"); !ok || got != "{ me { id } }" {
+		t.Fatalf("Get = %q, %v; want the stored query", got, ok)
+	}
+
+	// The prefix is a hash tag so that every key lands in one cluster slot.
+	for _, key := range []string{"{apq:}h1", "{apq:}index"} {
+		if !mr.Exists(key) {
+			t.Errorf("key %s missing among %v", key, mr.Keys())
+		}
+	}
+	if ttl := mr.TTL("{apq:}h1"); ttl != 0 {
+		t.Errorf("entry expires in %v without a TTL", ttl)
+	}
+}
+
+func TestRedisExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newRedis(t, time.Minute, 0)
+
+	c.Add(ctx, "h1", "query")
+	if ttl := mr.TTL("{apq:}h1"); ttl != time.Minute {
+		t.Fatalf("entry TTL %v, want 1m", ttl)
+	}
+	mr.FastForward(time.Minute)
+	if _, ok := c.Get(ctx, "h1"); ok {
+		t.Error("hit after the TTL elapsed")
+	}
+}
+
+func TestRedisEvictsOldestBeyondSize(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newRedis(t, 0, 2)
+
+	for _, key := range []string{"h1", "h2", "h3"} {
+		c.Add(ctx, key, "query "+key)
+		time.Sleep(2 * time.Millisecond) // distinct insertion scores
+	}
+
+	if _, ok := c.Get(ctx, "h1"); ok {
+		t.Error("oldest entry survived past the size cap")
+	}
+	for _, key := range []string{"h2", "h3"} {
+		if _, ok := c.Get(ctx, key); !ok {
+			t.Errorf("entry %s evicted", key)
+		}
+	}
+	if members, err := mr.ZMembers("{apq:}index"); err != nil || len(members) != 2 {
+		t.Errorf("index holds %v (%v), want 2 entries", members, err)
+	}
+}
+
+func TestRedisTreatsErrorsAsMisses(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newRedis(t, 0, 0)
+	mr.Close()
+
+	c.Add(ctx, "h1", "query")
+	if _, ok := c.Get(ctx, "h1"); ok {
+		t.Error("hit with Redis down")
+	}
+}
@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// ready requests the readiness endpoint of r and decodes its report.
+func ready(t *testing.T, r *Registry) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	r.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	return rec.Code, report
+}
+
+func up(context.Context) error { return nil }
+
+func TestReadinessReportsEveryCheck(t *testing.T) {
+	r := NewRegistry()
+	r.Register("store", 0, up)
+	r.Register("redis", time.Second, up)
+
+	code, report := ready(t, r)
+	if code != http.StatusOK || report.Status != StatusUp {
+		t.Errorf("got %d %q, want 200 %q", code, report.Status, StatusUp)
+	}
+	for _, name := range []string{"store", "redis"} {
+		if got := report.Checks[name]; got.Status != StatusUp || got.Error != "" || got.Duration == "" {
+			t.Errorf("check %s: %+v", name, got)
+		}
+	}
+
+	r.Register("redis", time.Second, func(context.Context) error { return errors.New("connection refused") })
+	code, report = ready(t, r)
+	if code != http.StatusServiceUnavailable || report.Status != StatusDown {
+		t.Errorf("got %d %q, want 503 %q", code, report.Status, StatusDown)
+	}
+	if got := report.Checks["redis"]; got.Status != StatusDown || got.Error != "connection refused" {
+		t.Errorf("failing check: %+v", got)
+	}
+	if got := report.Checks["store"]; got.Status != StatusUp {
+		t.Errorf("passing check beside a failing one: %+v", got)
+	}
+}
+
+func TestCheckExceedingTimeoutIsDown(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	r := NewRegistry()
+	// The check ignores its context, so Run must stop waiting on its own.
+	release := make(chan struct{})
+	defer close(release)
+	r.Register("stuck", timeout, func(context.Context) error {
+		<-release
+		return nil
+	})
+	r.Register("slow", timeout, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	code, report := ready(t, r)
+	if elapsed := time.Since(start); elapsed > 10*timeout {
+		t.Errorf("readiness took %v with a %v timeout", elapsed, timeout)
+	}
+	if code != http.StatusServiceUnavailable || report.Status != StatusDown {
+		t.Errorf("got %d %q, want 503 %q", code, report.Status, StatusDown)
+	}
+	for _, name := range []string{"stuck", "slow"} {
+		if got := report.Checks[name]; got.Status != StatusDown || got.Error != context.DeadlineExceeded.Error() {
+			t.Errorf("check %s: %+v", name, got)
+		}
+	}
+}
+
+func TestDrainFailsReadiness(t *testing.T) {
+	r := NewRegistry()
+	r.Register("store", 0, up)
+	if code, _ := ready(t, r); code != http.StatusOK {
+		t.Fatalf("before Drain: %d", code)
+	}
+
+	r.Drain()
+	if !r.Draining() {
+		t.Error("Draining() = false after Drain")
+	}
+	code, report := ready(t, r)
+	if code != http.StatusServiceUnavailable || report.Status != StatusDraining {
+		t.Errorf("got %d %q, want 503 %q", code, report.Status, StatusDraining)
+	}
+	if got := report.Checks["store"]; got.Status != StatusUp {
+		t.Errorf("checks still report while draining: %+v", got)
+	}
+
+	rec := httptest.NewRecorder()
+	LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("liveness while draining: %d", rec.Code)
+	}
+}
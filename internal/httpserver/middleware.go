@@ -0,0 +1,155 @@
+package httpserver
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/natnael_wondwoesn/GGStarter/config"
+)
+
+// BodyLimit rejects requests whose declared body exceeds limit bytes with
+// 413 and caps the bytes read from any other body at limit. Multipart POSTs
+// to uploadPaths are passed through: those paths must be served by a
+// handler with its own cap, such as gqlgen's transport.MultipartForm, which
+// handles every multipart POST and enforces its MaxUploadSize. The
+// Content-Type alone never raises the limit elsewhere.
+func BodyLimit(limit int64, uploadPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isMultipartPost(r) && slices.Contains(uploadPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > limit {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isMultipartPost mirrors the check transport.MultipartForm uses to claim a
+// request.
+func isMultipartPost(r *http.Request) bool {
+	if r.Method != http.MethodPost || r.Header.Get("Upgrade") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// CORS answers preflight requests and sets the Access-Control headers for
+// origins in cfg.AllowedOrigins. Requests from other origins pass through
+// without CORS headers, so browsers block them.
+func CORS(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	anyOrigin := slices.Contains(cfg.AllowedOrigins, "*")
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge / time.Second))
+	allowed := originAllowed(cfg)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Add("Vary", "Origin")
+			if !allowed(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Credentials are never sent with the wildcard: echoing every
+			// origin with them would let any site make credentialed
+			// requests. config.Validate rejects that combination.
+			if anyOrigin {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				if cfg.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+				if cfg.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", maxAge)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WebsocketOrigin returns the CheckOrigin function of a WebSocket
+// upgrader. Browsers apply no CORS checks to upgrades, so without it any
+// site could open a socket on a visitor's behalf. Upgrades without an
+// Origin header, from non-browser clients, and from the server's own
+// origin are accepted; other origins must be in cfg.AllowedOrigins and
+// CORS must be enabled.
+func WebsocketOrigin(cfg config.CORSConfig) func(r *http.Request) bool {
+	allowed := originAllowed(cfg)
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		return cfg.Enabled && allowed(origin)
+	}
+}
+
+// originAllowed reports whether an origin is in cfg.AllowedOrigins.
+func originAllowed(cfg config.CORSConfig) func(origin string) bool {
+	anyOrigin := slices.Contains(cfg.AllowedOrigins, "*")
+	return func(origin string) bool {
+		return anyOrigin || slices.ContainsFunc(cfg.AllowedOrigins, func(o string) bool {
+			return strings.EqualFold(o, origin)
+		})
+	}
+}
+
+// SecurityHeaders sets conservative browser security headers on every
+// response. Empty settings are omitted.
+func SecurityHeaders(cfg config.SecurityHeadersConfig) func(http.Handler) http.Handler {
+	set := map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"X-Frame-Options":         cfg.FrameOptions,
+		"Referrer-Policy":         cfg.ReferrerPolicy,
+		"Content-Security-Policy": cfg.ContentSecurityPolicy,
+	}
+	if cfg.HSTSMaxAge > 0 {
+		set["Strict-Transport-Security"] = "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge/time.Second)) + "; includeSubDomains"
+	}
+	for k, v := range set {
+		if v == "" {
+			delete(set, k)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for k, v := range set {
+				w.Header().Set(k, v)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
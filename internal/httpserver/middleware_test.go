@@ -0,0 +1,153 @@
+package httpserver
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/natnael_wondwoesn/GGStarter/config"
+	"github.com/natnael_wondwoesn/GGStarter/graph"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+)
+
+const (
+	jsonLimit   = 1 << 10
+	uploadLimit = 8 << 10
+)
+
+// newTestRouter serves the API on /query, with multipart uploads capped by
+// the transport, and echoes the length of any other body on /echo.
+func newTestRouter() http.Handler {
+	cfg := graph.Config{Resolvers: &graph.Resolver{Store: store.NewMemory(), Events: pubsub.NewHub[*model.Todo]()}}
+	srv := handler.New(graph.NewExecutableSchema(cfg))
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{MaxUploadSize: uploadLimit})
+
+	r := NewRouter(config.HTTPConfig{MaxBodyBytes: jsonLimit}, "/query")
+	r.Handle("/query", srv)
+	r.Post("/echo", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.Copy(io.Discard, r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+	})
+	return r
+}
+
+// query returns a todos query padded with whitespace to about size bytes.
+func query(size int) string {
+	return "{ todos { id } }" + strings.Repeat(" ", size)
+}
+
+// multipartBody returns a GraphQL multipart request running a query of
+// about size bytes.
+func multipartBody(t *testing.T, size int) (string, *bytes.Buffer) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	if err := mw.WriteField("operations", `{"query":"`+query(size)+`"}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.WriteField("map", "{}"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return mw.FormDataContentType(), body
+}
+
+func serve(h http.Handler, path, contentType string, body io.Reader, chunked bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	req.Header.Set("Content-Type", contentType)
+	if chunked {
+		req.ContentLength = -1
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestBodyLimitCapsJSONBodies(t *testing.T) {
+	h := newTestRouter()
+	body := `{"query":"` + query(2*jsonLimit) + `"}`
+	if rec := serve(h, "/query", "application/json", strings.NewReader(body), false); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized JSON body answered %d, want 413", rec.Code)
+	}
+	// Without a declared length the body is cut off while being read.
+	if rec := serve(h, "/query", "application/json", strings.NewReader(body), true); strings.Contains(rec.Body.String(), `"todos"`) {
+		t.Errorf("oversized chunked JSON body executed: %d %s", rec.Code, rec.Body)
+	}
+
+	rec := serve(h, "/query", "application/json", strings.NewReader(`{"query":"`+query(jsonLimit/2)+`"}`), false)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"todos"`) {
+		t.Errorf("JSON body within the limit: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestBodyLimitIgnoresMultipartContentTypeOffUploadPaths(t *testing.T) {
+	h := newTestRouter()
+	for _, chunked := range []bool{false, true} {
+		contentType, body := multipartBody(t, 2*jsonLimit)
+		if rec := serve(h, "/echo", contentType, body, chunked); rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("chunked=%v: multipart body to /echo answered %d, want 413", chunked, rec.Code)
+		}
+	}
+}
+
+func TestMultipartTransportCapsUploads(t *testing.T) {
+	h := newTestRouter()
+
+	contentType, body := multipartBody(t, 2*jsonLimit)
+	rec := serve(h, "/query", contentType, body, false)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"todos"`) {
+		t.Errorf("upload above the JSON limit: %d %s", rec.Code, rec.Body)
+	}
+
+	contentType, body = multipartBody(t, 2*uploadLimit)
+	rec = serve(h, "/query", contentType, body, false)
+	if !strings.Contains(rec.Body.String(), "request body too large") {
+		t.Errorf("upload above MaxUploadSize: %d %s", rec.Code, rec.Body)
+	}
+	contentType, body = multipartBody(t, 2*uploadLimit)
+	rec = serve(h, "/query", contentType, body, true)
+	if strings.Contains(rec.Body.String(), `"todos"`) {
+		t.Errorf("chunked upload above MaxUploadSize executed: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestCORSCredentials(t *testing.T) {
+	tests := []struct {
+		name            string
+		origins         []string
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{"listed origin", []string{"https://app.example.com"}, "https://app.example.com", "true"},
+		{"wildcard", []string{"*"}, "*", ""},
+		{"wildcard and listed origin", []string{"https://app.example.com", "*"}, "*", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := CORS(config.CORSConfig{AllowedOrigins: tt.origins, AllowCredentials: true})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+			req := httptest.NewRequest(http.MethodGet, "/query", nil)
+			req.Header.Set("Origin", "https://app.example.com")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+		})
+	}
+}
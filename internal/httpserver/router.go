@@ -0,0 +1,30 @@
+package httpserver
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/natnael_wondwoesn/GGStarter/config"
+	"github.com/natnael_wondwoesn/GGStarter/internal/requestid"
+)
+
+// NewRouter returns a chi router with the middleware chain configured by
+// cfg: real IP extraction, request IDs, body size limits, security headers
+// and CORS, in that order. Multipart POSTs to uploadPaths are exempt from
+// cfg.MaxBodyBytes and must be capped by their handler.
+func NewRouter(cfg config.HTTPConfig, uploadPaths ...string) chi.Router {
+	r := chi.NewRouter()
+	if cfg.TrustProxyHeaders {
+		r.Use(middleware.RealIP)
+	}
+	r.Use(requestid.Middleware(cfg.RequestIDHeader))
+	if cfg.MaxBodyBytes > 0 {
+		r.Use(BodyLimit(cfg.MaxBodyBytes, uploadPaths...))
+	}
+	if cfg.SecurityHeaders.Enabled {
+		r.Use(SecurityHeaders(cfg.SecurityHeaders))
+	}
+	if cfg.CORS.Enabled {
+		r.Use(CORS(cfg.CORS))
+	}
+	return r
+}
@@ -0,0 +1,177 @@
+// Package httpserver runs the HTTP server and shuts it down gracefully:
+// open WebSocket connections are closed, in-flight requests are drained for
+// a grace period and registered resources are released.
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/natnael_wondwoesn/GGStarter/config"
+	"go.uber.org/zap"
+)
+
+// Server wraps http.Server with signal-driven graceful shutdown.
+type Server struct {
+	srv    *http.Server
+	grace  time.Duration
+	delay  time.Duration
+	logger *zap.Logger
+
+	mu      sync.Mutex
+	conns   map[*wsConn]struct{}
+	hooks   []hook
+	onDrain []func()
+}
+
+type hook struct {
+	name string
+	fn   func(context.Context) error
+}
+
+type wsConn struct {
+	cancel context.CancelFunc
+}
+
+// New returns a Server listening on cfg.Port with the timeouts from cfg.
+func New(cfg config.ServerConfig, handler http.Handler, logger *zap.Logger) *Server {
+	s := &Server{
+		grace:  cfg.ShutdownTimeout,
+		delay:  cfg.DrainDelay,
+		logger: logger,
+		conns:  make(map[*wsConn]struct{}),
+	}
+	s.srv = &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           s.trackWebsockets(handler),
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          zap.NewStdLog(logger),
+	}
+	return s
+}
+
+// OnShutdown registers fn to run after HTTP traffic has drained. Hooks run in
+// registration order, so register dependents before what they depend on.
+func (s *Server) OnShutdown(name string, fn func(context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook{name: name, fn: fn})
+}
+
+// OnDrain registers fn to run as soon as shutdown begins, while the server
+// still accepts requests. Readiness checks use it to start failing.
+func (s *Server) OnDrain(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onDrain = append(s.onDrain, fn)
+}
+
+// Run serves until ctx is cancelled, then shuts down gracefully. It returns
+// the listener error if the server could not start.
+func (s *Server) Run(ctx context.Context) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("shutting down", zap.Duration("grace_period", s.grace))
+	s.drain()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.grace)
+	defer cancel()
+
+	s.closeWebsockets()
+	if err := s.srv.Shutdown(shutdownCtx); err != nil {
+		s.logger.Warn("grace period expired, closing remaining connections", zap.Error(err))
+		_ = s.srv.Close()
+	}
+	if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.logger.Error("server error", zap.Error(err))
+	}
+
+	s.runHooks()
+	s.logger.Info("shutdown complete")
+	return nil
+}
+
+// drain runs the OnDrain callbacks and then keeps serving for the drain
+// delay, giving load balancers time to observe failing readiness probes
+// before the listener closes.
+func (s *Server) drain() {
+	s.mu.Lock()
+	fns := s.onDrain
+	s.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+	if s.delay > 0 {
+		s.logger.Info("draining", zap.Duration("delay", s.delay))
+		time.Sleep(s.delay)
+	}
+}
+
+func (s *Server) runHooks() {
+	s.mu.Lock()
+	hooks := s.hooks
+	s.mu.Unlock()
+
+	for _, h := range hooks {
+		ctx, cancel := context.WithTimeout(context.Background(), s.grace)
+		if err := h.fn(ctx); err != nil {
+			s.logger.Error("shutdown hook failed", zap.String("hook", h.name), zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+// trackWebsockets gives every upgrade request a cancellable context.
+// http.Server.Shutdown does not wait for or close hijacked connections, so
+// they are cancelled explicitly; gqlgen then completes the active
+// subscriptions and sends a normal-closure close frame.
+func (s *Server) trackWebsockets(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		conn := &wsConn{cancel: cancel}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		defer func() {
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			cancel()
+		}()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (s *Server) closeWebsockets() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n := len(s.conns); n > 0 {
+		s.logger.Info("closing websocket connections", zap.Int("count", n))
+	}
+	for conn := range s.conns {
+		conn.cancel()
+	}
+}
@@ -0,0 +1,183 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+	"github.com/natnael_wondwoesn/GGStarter/config"
+	"github.com/natnael_wondwoesn/GGStarter/graph"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+	"go.uber.org/zap"
+)
+
+// freePort returns a port that was free a moment ago.
+func freePort(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+}
+
+// subscriptionHandler serves the API with subscriptions over graphql-ws,
+// treating every connection as an authenticated user.
+func subscriptionHandler() http.Handler {
+	cfg := graph.Config{Resolvers: &graph.Resolver{Store: store.NewMemory(), Events: pubsub.NewHub[*model.Todo]()}}
+	cfg.Directives.Auth = graph.Auth
+	cfg.Directives.HasRole = graph.HasRole
+	srv := handler.New(graph.NewExecutableSchema(cfg))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.Websocket{
+		Upgrader: websocket.Upgrader{Subprotocols: []string{"graphql-transport-ws"}},
+		InitFunc: func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+			claims := &auth.Claims{}
+			claims.Subject = "alice"
+			return auth.WithClaims(ctx, claims), &payload, nil
+		},
+	})
+	return srv
+}
+
+// subscribe opens a todoCreated subscription on addr and returns the
+// connection once it is acknowledged.
+func subscribe(t *testing.T, addr string) *websocket.Conn {
+	t.Helper()
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}}
+	var conn *websocket.Conn
+	var err error
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if conn, _, err = dialer.Dial("ws://"+addr+"/", nil); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	if err := conn.WriteJSON(map[string]any{"type": "connection_init"}); err != nil {
+		t.Fatal(err)
+	}
+	var ack struct{ Type string }
+	if err := conn.ReadJSON(&ack); err != nil || ack.Type != "connection_ack" {
+		t.Fatalf("connection_init answered with %+v, %v", ack, err)
+	}
+	payload, _ := json.Marshal(map[string]string{"query": "subscription { todoCreated { id } }"})
+	err = conn.WriteJSON(map[string]any{"id": "1", "type": "subscribe", "payload": json.RawMessage(payload)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestRunShutsDownGracefully(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	port := freePort(t)
+	addr := "127.0.0.1:" + port
+	s := New(config.ServerConfig{Port: port, ShutdownTimeout: 2 * time.Second, DrainDelay: delay}, subscriptionHandler(), zap.NewNop())
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	// closed receives the close code of the websocket once the server ends it.
+	closed := make(chan int, 1)
+
+	var drainStart time.Time
+	s.OnDrain(func() {
+		drainStart = time.Now()
+		record("drain")
+		// The listener stays open for the drain delay.
+		resp, err := http.Get("http://" + addr + "/?query=%7B__typename%7D")
+		if err != nil {
+			t.Errorf("request during drain delay: %v", err)
+			return
+		}
+		resp.Body.Close()
+		record("served while draining")
+	})
+	s.OnShutdown("subscriptions", func(context.Context) error {
+		select {
+		case code := <-closed:
+			if code != websocket.CloseNormalClosure {
+				t.Errorf("websocket closed with code %d, want %d", code, websocket.CloseNormalClosure)
+			}
+			record("websocket closed")
+		case <-time.After(time.Second):
+			t.Error("websocket still open when shutdown hooks ran")
+		}
+		if elapsed := time.Since(drainStart); elapsed < delay {
+			t.Errorf("hooks ran %v after drain began, want at least %v", elapsed, delay)
+		}
+		record("hook subscriptions")
+		return nil
+	})
+	s.OnShutdown("store", func(context.Context) error {
+		record("hook store")
+		return errors.New("ignored")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	conn := subscribe(t, addr)
+	defer conn.Close()
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				var ce *websocket.CloseError
+				if errors.As(err, &ce) {
+					closed <- ce.Code
+				} else {
+					closed <- -1
+				}
+				return
+			}
+		}
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+
+	want := []string{"drain", "served while draining", "websocket closed", "hook subscriptions", "hook store"}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != len(want) {
+		t.Fatalf("events %q, want %q", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("events %q, want %q", events, want)
+		}
+	}
+	if _, err := net.DialTimeout("tcp", addr, 100*time.Millisecond); err == nil {
+		t.Error("listener still accepts connections after Run returned")
+	}
+}
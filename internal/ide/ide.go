@@ -0,0 +1,215 @@
+// Package ide serves an in-browser GraphQL IDE pointed at the API: GraphiQL,
+// GraphQL Playground or Apollo Sandbox. The IDE can be pre-filled with
+// request headers, such as a development token, and with example
+// operations, each opened in its own tab.
+package ide
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql/playground"
+	"go.uber.org/zap"
+)
+
+// Kind selects the IDE.
+type Kind string
+
+const (
+	Off        Kind = "off"
+	GraphiQL   Kind = "graphiql"
+	Playground Kind = "playground"
+	Sandbox    Kind = "sandbox"
+)
+
+// Tab is an example operation opened in its own tab.
+type Tab struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// Options configures the IDE page.
+type Options struct {
+	Title string
+	// Endpoint is the GraphQL endpoint, either a path on the same origin
+	// such as "/query" or an absolute URL.
+	Endpoint string
+	// Headers pre-fill the headers editor.
+	Headers map[string]string
+	Tabs    []Tab
+	// Logger reports pages that fail to render. It defaults to a no-op.
+	Logger *zap.Logger
+}
+
+// Handler returns the page of the IDE kind. Apollo Sandbox has no tabs
+// and opens only the first one.
+func Handler(kind Kind, opts Options) (http.Handler, error) {
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop()
+	}
+	switch kind {
+	case GraphiQL:
+		return page(graphiqlPage, opts), nil
+	case Playground:
+		return page(playgroundPage, opts), nil
+	case Sandbox:
+		sandboxOpts := []playground.ApolloSandboxOption{
+			playground.WithApolloSandboxEndpointIsEditable(false),
+		}
+		if len(opts.Headers) > 0 {
+			headers := make(map[string]any, len(opts.Headers))
+			for k, v := range opts.Headers {
+				headers[k] = v
+			}
+			sandboxOpts = append(sandboxOpts, playground.WithApolloSandboxInitialStateHeaders(headers))
+		}
+		if len(opts.Tabs) > 0 {
+			sandboxOpts = append(sandboxOpts, playground.WithApolloSandboxInitialStateDocument(opts.Tabs[0].Query))
+		}
+		return playground.ApolloSandboxHandler(opts.Title, opts.Endpoint, sandboxOpts...), nil
+	default:
+		return nil, fmt.Errorf("unknown ide %q", kind)
+	}
+}
+
+// LoadTabs reads every .graphql file in dir as a tab named after the file,
+// in name order.
+func LoadTabs(dir string) ([]Tab, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.graphql"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	tabs := make([]Tab, 0, len(paths))
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		tabs = append(tabs, Tab{
+			Name:  strings.TrimSuffix(filepath.Base(p), ".graphql"),
+			Query: string(b),
+		})
+	}
+	return tabs, nil
+}
+
+// page renders tmpl into a buffer so that a failure is reported as a 500
+// rather than a truncated page.
+func page(tmpl *template.Template, opts Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, opts); err != nil {
+			opts.Logger.Error("render ide", zap.String("ide", tmpl.Name()), zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		buf.WriteTo(w)
+	})
+}
+
+// The endpoint script resolves a relative Endpoint against the page's
+// origin for both HTTP and WebSocket.
+const endpointScript = `
+      const endpoint = {{.Endpoint}};
+      const url = /^https?:/.test(endpoint) ? endpoint : location.protocol + '//' + location.host + endpoint;
+      const subscriptionUrl = url.replace(/^http/, 'ws');
+      const headers = {{if .Headers}}{{.Headers}}{{else}}{}{{end}};
+      const tabs = {{if .Tabs}}{{.Tabs}}{{else}}[]{{end}};`
+
+var graphiqlPage = template.Must(template.New("graphiql").Parse(`<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8">
+    <title>{{.Title}}</title>
+    <style>
+      body { height: 100%; margin: 0; width: 100%; overflow: hidden; }
+      #graphiql { height: 100vh; }
+    </style>
+    <script
+      src="https://cdn.jsdelivr.net/npm/react@18.2.0/umd/react.production.min.js"
+      integrity="sha256-S0lp+k7zWUMk2ixteM6HZvu8L9Eh//OVrt+ZfbCpmgY="
+      crossorigin="anonymous"
+    ></script>
+    <script
+      src="https://cdn.jsdelivr.net/npm/react-dom@18.2.0/umd/react-dom.production.min.js"
+      integrity="sha256-IXWO0ITNDjfnNXIu5POVfqlgYoop36bDzhodR6LW5Pc="
+      crossorigin="anonymous"
+    ></script>
+    <link
+      rel="stylesheet"
+      href="https://cdn.jsdelivr.net/npm/graphiql@3.7.0/graphiql.min.css"
+      integrity="sha256-Dbkv2LUWis+0H4Z+IzxLBxM2ka1J133lSjqqtSu49o8="
+      crossorigin="anonymous"
+    />
+  </head>
+  <body>
+    <div id="graphiql">Loading...</div>
+    <script
+      src="https://cdn.jsdelivr.net/npm/graphiql@3.7.0/graphiql.min.js"
+      integrity="sha256-qsScAZytFdTAEOM8REpljROHu8DvdvxXBK7xhoq5XD0="
+      crossorigin="anonymous"
+    ></script>
+    <script>` + endpointScript + `
+      const headersJSON = JSON.stringify(headers, null, 2);
+      ReactDOM.render(
+        React.createElement(GraphiQL, {
+          fetcher: GraphiQL.createFetcher({ url, subscriptionUrl }),
+          isHeadersEditorEnabled: true,
+          shouldPersistHeaders: true,
+          defaultHeaders: headersJSON,
+          defaultTabs: tabs.length ? tabs.map((t) => ({ query: t.query, headers: headersJSON })) : undefined,
+        }),
+        document.getElementById('graphiql'),
+      );
+    </script>
+  </body>
+</html>
+`))
+
+// graphql-playground-react is no longer published with integrity hashes;
+// these are the ones gqlgen v0.14.0, its last release serving it, pinned.
+// Run the package tests with -ide.check-assets to check them, and the
+// GraphiQL hashes, against the files the CDN serves.
+var playgroundPage = template.Must(template.New("playground").Parse(`<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8">
+    <meta name="viewport" content="user-scalable=no, initial-scale=1.0, minimum-scale=1.0, maximum-scale=1.0, minimal-ui">
+    <title>{{.Title}}</title>
+    <link
+      rel="stylesheet"
+      href="https://cdn.jsdelivr.net/npm/graphql-playground-react@1.7.26/build/static/css/index.css"
+      integrity="sha256-dKnNLEFwKSVFpkpjRWe+o/jQDM6n/JsvQ0J3l5Dk3fc="
+      crossorigin="anonymous"
+    />
+    <script
+      src="https://cdn.jsdelivr.net/npm/graphql-playground-react@1.7.26/build/static/js/middleware.js"
+      integrity="sha256-SG9YAy4eywTcLckwij7V4oSCG3hOdV1m+2e1XuNxIgk="
+      crossorigin="anonymous"
+    ></script>
+  </head>
+  <body>
+    <div id="root"></div>
+    <script>` + endpointScript + `
+      window.addEventListener('load', function () {
+        GraphQLPlayground.init(document.getElementById('root'), {
+          endpoint: url,
+          subscriptionEndpoint: subscriptionUrl,
+          headers: headers,
+          tabs: tabs.length ? tabs.map((t) => ({ endpoint: url, name: t.name, query: t.query, headers: headers })) : undefined,
+          settings: { 'request.credentials': 'same-origin' },
+        });
+      });
+    </script>
+  </body>
+</html>
+`))
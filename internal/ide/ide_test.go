@@ -0,0 +1,128 @@
+package ide
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"flag"
+	"hash"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func render(t *testing.T, h http.Handler) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec
+}
+
+// assetTag matches a script or stylesheet loaded from another origin.
+// Icons run no code and need no integrity.
+var assetTag = regexp.MustCompile(`<script\b[^>]*\bsrc="https://[^"]*"[^>]*>|<link\b[^>]*\brel="stylesheet"[^>]*>`)
+
+var checkAssets = flag.Bool("ide.check-assets", false, "fetch every IDE asset and check it against its integrity hash")
+
+// assets returns the src or href and the integrity of every cross-origin
+// asset the page of kind loads.
+func assets(t *testing.T, kind Kind) map[string]string {
+	t.Helper()
+	opts := Options{Title: "API", Endpoint: "/query", Headers: map[string]string{"Authorization": "Bearer dev"}, Tabs: []Tab{{Name: "me", Query: "{ me { id } }"}}}
+	h, err := Handler(kind, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := render(t, h)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("status %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	tags := assetTag.FindAllString(rec.Body.String(), -1)
+	if len(tags) == 0 {
+		t.Fatal("page loads no assets")
+	}
+	found := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		url := assetURL.FindStringSubmatch(tag)
+		integrity := assetIntegrity.FindStringSubmatch(tag)
+		if url == nil || integrity == nil || !strings.Contains(tag, `crossorigin="anonymous"`) {
+			t.Errorf("asset without integrity: %s", tag)
+			continue
+		}
+		found[url[1]] = integrity[1]
+	}
+	return found
+}
+
+var (
+	assetURL       = regexp.MustCompile(`\b(?:src|href)="(https://[^"]+)"`)
+	assetIntegrity = regexp.MustCompile(`\bintegrity="(sha(?:256|384|512)-[^"]+)"`)
+)
+
+func TestPagesPinAssets(t *testing.T) {
+	for _, kind := range []Kind{GraphiQL, Playground, Sandbox} {
+		t.Run(string(kind), func(t *testing.T) {
+			assets(t, kind)
+		})
+	}
+}
+
+// TestPinnedHashesMatchPublishedAssets downloads every asset and checks
+// that its integrity hash is that of the published file, so a wrong hash
+// is caught before it blanks the page in browsers. It needs network
+// access: run it with -ide.check-assets.
+func TestPinnedHashesMatchPublishedAssets(t *testing.T) {
+	if !*checkAssets {
+		t.Skip("fetches assets from their CDNs; run with -ide.check-assets")
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	for _, kind := range []Kind{GraphiQL, Playground, Sandbox} {
+		for url, integrity := range assets(t, kind) {
+			t.Run(string(kind)+" "+url, func(t *testing.T) {
+				resp, err := client.Get(url)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("GET %s: %s", url, resp.Status)
+				}
+
+				algorithm, _, _ := strings.Cut(integrity, "-")
+				h := map[string]hash.Hash{"sha256": sha256.New(), "sha384": sha512.New384(), "sha512": sha512.New()}[algorithm]
+				if _, err := io.Copy(h, resp.Body); err != nil {
+					t.Fatal(err)
+				}
+				if got := algorithm + "-" + base64.StdEncoding.EncodeToString(h.Sum(nil)); got != integrity {
+					t.Errorf("published asset hashes to %s, page pins %s", got, integrity)
+				}
+			})
+		}
+	}
+}
+
+func TestPageReportsRenderErrors(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	broken := template.Must(template.New("broken").Parse("<html>{{.Missing}}</html>"))
+
+	rec := render(t, page(broken, Options{Logger: zap.New(core)}))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "<html>") {
+		t.Errorf("partial page sent: %q", rec.Body)
+	}
+	if logs.FilterMessage("render ide").Len() != 1 {
+		t.Errorf("render error not logged: %v", logs.All())
+	}
+}
@@ -0,0 +1,18 @@
+// Package logging builds the application's zap logger.
+package logging
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// New returns a logger suited to mode: JSON at info level in production, and
+// colored console output at debug level otherwise.
+func New(mode string) (*zap.Logger, error) {
+	if mode == "production" {
+		return zap.NewProduction()
+	}
+	cfg := zap.NewDevelopmentConfig()
+	cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	return cfg.Build()
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+type instrumentedCache[T any] struct {
+	graphql.Cache[T]
+	hits, misses interface{ Inc() }
+}
+
+// InstrumentCache wraps c so that every Get is counted as a hit or miss
+// under the given cache name.
+func InstrumentCache[T any](m *Metrics, name string, c graphql.Cache[T]) graphql.Cache[T] {
+	return &instrumentedCache[T]{
+		Cache:  c,
+		hits:   m.cacheRequests.WithLabelValues(name, "hit"),
+		misses: m.cacheRequests.WithLabelValues(name, "miss"),
+	}
+}
+
+func (c *instrumentedCache[T]) Get(ctx context.Context, key string) (T, bool) {
+	v, ok := c.Cache.Get(ctx, key)
+	if ok {
+		c.hits.Inc()
+	} else {
+		c.misses.Inc()
+	}
+	return v, ok
+}
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Label values recorded in place of unknown operation names and error codes.
+const (
+	OtherOperation = "other"
+	OtherErrorCode = "OTHER"
+)
+
+// Extension records operation, resolver, error and subscription metrics.
+// ResolverSampleRate is the fraction of resolver calls timed, from 0 to 1.
+//
+// Operation names and error codes become label values, so each is limited
+// to a known set; anything else is recorded as OtherOperation or
+// OtherErrorCode, and a client sending random names cannot create new
+// series.
+type Extension struct {
+	Metrics            *Metrics
+	ResolverSampleRate float64
+	// KnownOperation reports whether an operation name is recorded as is.
+	// Nil records every named operation as OtherOperation.
+	KnownOperation func(name string) bool
+	// ErrorCodes are the extensions.code values recorded as is.
+	ErrorCodes []string
+}
+
+var (
+	_ graphql.HandlerExtension     = Extension{}
+	_ graphql.OperationInterceptor = Extension{}
+	_ graphql.ResponseInterceptor  = Extension{}
+	_ graphql.FieldInterceptor     = Extension{}
+)
+
+func (Extension) ExtensionName() string {
+	return "Metrics"
+}
+
+func (Extension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation tracks subscriptions, which stay open across many
+// responses, from start until their stream or context ends.
+func (e Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	opCtx := graphql.GetOperationContext(ctx)
+	if opCtx.Operation == nil || opCtx.Operation.Operation != ast.Subscription {
+		return next(ctx)
+	}
+
+	name, opType := e.labels(opCtx)
+	e.Metrics.requests.WithLabelValues(name, opType).Inc()
+	e.Metrics.activeSubscriptions.Inc()
+
+	var once sync.Once
+	done := func() { once.Do(e.Metrics.activeSubscriptions.Dec) }
+	go func() {
+		<-ctx.Done()
+		done()
+	}()
+
+	handler := next(ctx)
+	return func(ctx context.Context) *graphql.Response {
+		resp := handler(ctx)
+		if resp == nil {
+			done()
+		}
+		return resp
+	}
+}
+
+func (e Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil {
+		return resp
+	}
+
+	for _, err := range resp.Errors {
+		code, _ := err.Extensions["code"].(string)
+		switch {
+		case code == "":
+			code = "UNKNOWN"
+		case !slices.Contains(e.ErrorCodes, code):
+			code = OtherErrorCode
+		}
+		e.Metrics.errors.WithLabelValues(code).Inc()
+	}
+
+	if !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+	opCtx := graphql.GetOperationContext(ctx)
+	if opCtx.Operation != nil && opCtx.Operation.Operation == ast.Subscription {
+		return resp
+	}
+	name, opType := e.labels(opCtx)
+	e.Metrics.requests.WithLabelValues(name, opType).Inc()
+	e.Metrics.requestDuration.WithLabelValues(name, opType).Observe(time.Since(opCtx.Stats.OperationStart).Seconds())
+	return resp
+}
+
+func (e Extension) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver || e.ResolverSampleRate <= 0 || rand.Float64() >= e.ResolverSampleRate {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	res, err := next(ctx)
+	e.Metrics.resolverDuration.WithLabelValues(fc.Object + "." + fc.Field.Name).Observe(time.Since(start).Seconds())
+	return res, err
+}
+
+func (e Extension) labels(opCtx *graphql.OperationContext) (name, opType string) {
+	name = opCtx.OperationName
+	opType = "unknown"
+	if opCtx.Operation != nil {
+		opType = string(opCtx.Operation.Operation)
+		if name == "" {
+			name = opCtx.Operation.Name
+		}
+	}
+	switch {
+	case name == "":
+		name = "anonymous"
+	case e.KnownOperation == nil || !e.KnownOperation(name):
+		name = OtherOperation
+	}
+	return name, opType
+}
@@ -0,0 +1,89 @@
+// Package metrics collects Prometheus metrics for the GraphQL server and
+// serves them in the text exposition format.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "graphql"
+
+// Metrics owns a registry and the collectors recorded by the GraphQL
+// extension and instrumented caches.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests            *prometheus.CounterVec
+	requestDuration     *prometheus.HistogramVec
+	resolverDuration    *prometheus.HistogramVec
+	errors              *prometheus.CounterVec
+	activeSubscriptions prometheus.Gauge
+	cacheRequests       *prometheus.CounterVec
+}
+
+// New registers all collectors, plus the Go runtime and process collectors,
+// on a fresh registry.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "GraphQL operations executed, by known operation name and type.",
+		}, []string{"operation", "type"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Time from receiving a GraphQL operation to writing its response.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "type"}),
+		resolverDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "resolver_duration_seconds",
+			Help:      "Latency of sampled field resolvers, by Type.field.",
+			Buckets:   []float64{.0005, .001, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"field"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "errors_total",
+			Help:      "GraphQL errors returned to clients, by extensions.code.",
+		}, []string{"code"}),
+		activeSubscriptions: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_subscriptions",
+			Help:      "Subscriptions currently streaming to clients.",
+		}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_requests_total",
+			Help:      "Cache lookups by cache and result (hit or miss). The hit ratio is hits over the total.",
+		}, []string{"cache", "result"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.resolverDuration,
+		m.errors,
+		m.activeSubscriptions,
+		m.cacheRequests,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Registry returns the underlying registry so that other subsystems can add
+// their own collectors.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/natnael_wondwoesn/GGStarter/graph"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// serve runs query against the API with e installed.
+func serve(t *testing.T, e Extension, query string) {
+	t.Helper()
+	cfg := graph.Config{Resolvers: &graph.Resolver{Store: store.NewMemory(), Events: pubsub.NewHub[*model.Todo]()}}
+	srv := handler.New(graph.NewExecutableSchema(cfg))
+	srv.AddTransport(transport.POST{})
+	srv.Use(e)
+
+	body, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestOperationNamesOutsideTheKnownSetAreCollapsed(t *testing.T) {
+	m := New()
+	e := Extension{Metrics: m, KnownOperation: func(name string) bool { return name == "ListTodos" }}
+
+	serve(t, e, `query ListTodos { todos { id } }`)
+	serve(t, e, `query ListTodos { todos { id } }`)
+	serve(t, e, `{ todos { id } }`)
+	for _, name := range []string{"Random1", "Random2", "Random3"} {
+		serve(t, e, `query `+name+` { todos { id } }`)
+	}
+
+	for _, tt := range []struct {
+		operation string
+		want      float64
+	}{
+		{"ListTodos", 2},
+		{"anonymous", 1},
+		{OtherOperation, 3},
+	} {
+		if got := testutil.ToFloat64(m.requests.WithLabelValues(tt.operation, "query")); got != tt.want {
+			t.Errorf("requests_total{operation=%q} = %v, want %v", tt.operation, got, tt.want)
+		}
+	}
+	if n := testutil.CollectAndCount(m.requests); n != 3 {
+		t.Errorf("requests_total has %d series, want 3", n)
+	}
+	if n := testutil.CollectAndCount(m.requestDuration); n != 3 {
+		t.Errorf("request_duration_seconds has %d series, want 3", n)
+	}
+
+	// Without KnownOperation every named operation is collapsed.
+	m = New()
+	serve(t, Extension{Metrics: m}, `query ListTodos { todos { id } }`)
+	if got := testutil.ToFloat64(m.requests.WithLabelValues(OtherOperation, "query")); got != 1 {
+		t.Errorf("requests_total{operation=%q} = %v, want 1", OtherOperation, got)
+	}
+}
+
+func TestErrorCodesOutsideTheKnownSetAreCollapsed(t *testing.T) {
+	m := New()
+	e := Extension{Metrics: m, ErrorCodes: []string{"GRAPHQL_VALIDATION_FAILED"}}
+
+	serve(t, e, `{ nope }`)       // GRAPHQL_VALIDATION_FAILED
+	serve(t, e, `{ todos { id }`) // GRAPHQL_PARSE_FAILED
+
+	if got := testutil.ToFloat64(m.errors.WithLabelValues("GRAPHQL_VALIDATION_FAILED")); got != 1 {
+		t.Errorf("errors_total{code=GRAPHQL_VALIDATION_FAILED} = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.errors.WithLabelValues(OtherErrorCode)); got != 1 {
+		t.Errorf("errors_total{code=%s} = %v, want 1", OtherErrorCode, got)
+	}
+}
+
+// waitFor polls the gauge until it reads want.
+func waitFor(t *testing.T, m *Metrics, want float64) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for testutil.ToFloat64(m.activeSubscriptions) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("active_subscriptions = %v, want %v", testutil.ToFloat64(m.activeSubscriptions), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestActiveSubscriptions(t *testing.T) {
+	m := New()
+	e := Extension{Metrics: m}
+
+	// subscribe starts a subscription whose stream yields n responses
+	// before ending.
+	subscribe := func(ctx context.Context, n int) graphql.ResponseHandler {
+		ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{
+			OperationName: "OnTodo",
+			Operation:     &ast.OperationDefinition{Operation: ast.Subscription},
+		})
+		return e.InterceptOperation(ctx, func(context.Context) graphql.ResponseHandler {
+			return func(context.Context) *graphql.Response {
+				if n == 0 {
+					return nil
+				}
+				n--
+				return &graphql.Response{}
+			}
+		})
+	}
+
+	ended := subscribe(context.Background(), 1)
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	subscribe(cancelCtx, 1)
+	waitFor(t, m, 2)
+
+	// A stream that ends decrements the gauge once, however often it is
+	// polled afterwards.
+	if ended(context.Background()) == nil {
+		t.Fatal("stream ended early")
+	}
+	waitFor(t, m, 2)
+	ended(context.Background())
+	ended(context.Background())
+	waitFor(t, m, 1)
+
+	// So does a client going away before its stream ends.
+	cancel()
+	waitFor(t, m, 0)
+
+	if got := testutil.ToFloat64(m.requests.WithLabelValues(OtherOperation, "subscription")); got != 2 {
+		t.Errorf("requests_total for subscriptions = %v, want 2", got)
+	}
+}
+
+func TestInstrumentCache(t *testing.T) {
+	m := New()
+	ctx := context.Background()
+	c := InstrumentCache(m, "query", graphql.Cache[string](lru.New[string](10)))
+
+	c.Get(ctx, "a")
+	c.Add(ctx, "a", "document")
+	c.Get(ctx, "a")
+	c.Get(ctx, "a")
+	c.Get(ctx, "b")
+	InstrumentCache(m, "apq", graphql.Cache[string](lru.New[string](10))).Get(ctx, "a")
+
+	for _, tt := range []struct {
+		cache, result string
+		want          float64
+	}{
+		{"query", "hit", 2},
+		{"query", "miss", 2},
+		{"apq", "hit", 0},
+		{"apq", "miss", 1},
+	} {
+		if got := testutil.ToFloat64(m.cacheRequests.WithLabelValues(tt.cache, tt.result)); got != tt.want {
+			t.Errorf("cache_requests_total{cache=%q,result=%q} = %v, want %v", tt.cache, tt.result, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Package pubsub provides an in-process publish/subscribe hub used to fan out
+// domain events to GraphQL subscriptions.
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBuffer is the per-subscriber channel capacity. A subscriber that falls
+// further behind than this drops events instead of blocking publishers.
+const DefaultBuffer = 16
+
+// Hub fans out published values to every subscriber of a topic.
+type Hub[T any] struct {
+	mu     sync.RWMutex
+	topics map[string]map[*subscriber[T]]struct{}
+	done   chan struct{}
+	closed bool
+}
+
+type subscriber[T any] struct {
+	ch chan T
+}
+
+// NewHub returns an empty hub ready for use.
+func NewHub[T any]() *Hub[T] {
+	return &Hub[T]{
+		topics: make(map[string]map[*subscriber[T]]struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Subscribe registers interest in topic. The returned channel is closed when
+// ctx is cancelled or the hub is closed.
+func (h *Hub[T]) Subscribe(ctx context.Context, topic string) <-chan T {
+	sub := &subscriber[T]{ch: make(chan T, DefaultBuffer)}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		close(sub.ch)
+		return sub.ch
+	}
+	subs, ok := h.topics[topic]
+	if !ok {
+		subs = make(map[*subscriber[T]]struct{})
+		h.topics[topic] = subs
+	}
+	subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-h.done:
+		}
+		h.unsubscribe(topic, sub)
+	}()
+
+	return sub.ch
+}
+
+func (h *Hub[T]) unsubscribe(topic string, sub *subscriber[T]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subs, ok := h.topics[topic]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.topics, topic)
+	}
+	close(sub.ch)
+}
+
+// Publish delivers v to all current subscribers of topic without blocking.
+func (h *Hub[T]) Publish(topic string, v T) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.topics[topic] {
+		select {
+		case sub.ch <- v:
+		default:
+		}
+	}
+}
+
+// Close terminates every subscription. Subsequent subscriptions receive an
+// already closed channel.
+func (h *Hub[T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	close(h.done)
+	for topic, subs := range h.topics {
+		for sub := range subs {
+			close(sub.ch)
+		}
+		delete(h.topics, topic)
+	}
+}
@@ -0,0 +1,137 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// receive returns the next value from ch, failing when none arrives soon or
+// ch is closed.
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("no value received")
+	}
+	panic("unreachable")
+}
+
+// closed reports whether ch is closed within a second, draining any
+// buffered values first.
+func closed[T any](ch <-chan T) bool {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+func TestPublishDeliversToTopicSubscribers(t *testing.T) {
+	h := NewHub[int]()
+	ctx := context.Background()
+	a := h.Subscribe(ctx, "a")
+	a2 := h.Subscribe(ctx, "a")
+	b := h.Subscribe(ctx, "b")
+
+	h.Publish("a", 1)
+	h.Publish("b", 2)
+	h.Publish("c", 3)
+
+	if got := receive(t, a); got != 1 {
+		t.Errorf("first subscriber of a received %d, want 1", got)
+	}
+	if got := receive(t, a2); got != 1 {
+		t.Errorf("second subscriber of a received %d, want 1", got)
+	}
+	if got := receive(t, b); got != 2 {
+		t.Errorf("subscriber of b received %d, want 2", got)
+	}
+	select {
+	case v := <-a:
+		t.Errorf("subscriber of a received %d from another topic", v)
+	default:
+	}
+}
+
+func TestCancelUnsubscribes(t *testing.T) {
+	h := NewHub[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := h.Subscribe(ctx, "a")
+	other := h.Subscribe(context.Background(), "a")
+
+	cancel()
+	if !closed(ch) {
+		t.Fatal("channel still open after cancel")
+	}
+
+	h.Publish("a", 1)
+	if got := receive(t, other); got != 1 {
+		t.Errorf("remaining subscriber received %d, want 1", got)
+	}
+	h.mu.RLock()
+	n := len(h.topics["a"])
+	h.mu.RUnlock()
+	if n != 1 {
+		t.Errorf("topic has %d subscribers, want 1", n)
+	}
+}
+
+func TestClose(t *testing.T) {
+	h := NewHub[int]()
+	ch := h.Subscribe(context.Background(), "a")
+
+	h.Close()
+	if !closed(ch) {
+		t.Fatal("channel still open after Close")
+	}
+	if !closed(h.Subscribe(context.Background(), "a")) {
+		t.Error("subscription after Close is open")
+	}
+	h.Publish("a", 1) // must not panic on closed channels
+	h.Close()         // nor may a second Close
+}
+
+func TestPublishDropsForSlowSubscriber(t *testing.T) {
+	h := NewHub[int]()
+	slow := h.Subscribe(context.Background(), "a")
+	fast := h.Subscribe(context.Background(), "a")
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := range DefaultBuffer + 10 {
+			h.Publish("a", i)
+			<-fast
+		}
+	}()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a full subscriber")
+	}
+
+	// The slow subscriber keeps the first DefaultBuffer values; the rest
+	// were dropped.
+	for want := range DefaultBuffer {
+		if got := receive(t, slow); got != want {
+			t.Fatalf("received %d, want %d", got, want)
+		}
+	}
+	select {
+	case v := <-slow:
+		t.Errorf("received %d beyond the buffer", v)
+	default:
+	}
+}
@@ -0,0 +1,142 @@
+package pubsub_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+	"github.com/natnael_wondwoesn/GGStarter/config"
+	"github.com/natnael_wondwoesn/GGStarter/graph"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+)
+
+type message struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// TestWebsocketSubscription subscribes to todoCreated over graphql-ws and
+// receives a todo created by a mutation over HTTP.
+func TestWebsocketSubscription(t *testing.T) {
+	jwtConfig := config.JWTConfig{Secret: "subscription-test-secret", Expiration: 1}
+	verifier, err := auth.NewVerifier(jwtConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := auth.NewIssuer(jwtConfig).Issue("alice", []string{string(model.RoleUser)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := store.NewMemory()
+	if err := db.CreateUser(t.Context(), &model.User{ID: "alice", Name: "Alice"}); err != nil {
+		t.Fatal(err)
+	}
+	hub := pubsub.NewHub[*model.Todo]()
+	defer hub.Close()
+	cfg := graph.Config{Resolvers: &graph.Resolver{Store: db, Events: hub}}
+	cfg.Directives.Auth = graph.Auth
+	cfg.Directives.HasRole = graph.HasRole
+	srv := handler.New(graph.NewExecutableSchema(cfg))
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.Websocket{
+		Upgrader: websocket.Upgrader{Subprotocols: []string{"graphql-transport-ws"}},
+		InitFunc: auth.WebsocketInit(verifier),
+	})
+	ts := httptest.NewServer(auth.Middleware(verifier, srv))
+	defer ts.Close()
+
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	init, _ := json.Marshal(map[string]string{"Authorization": "Bearer " + token})
+	if err := conn.WriteJSON(message{Type: "connection_init", Payload: init}); err != nil {
+		t.Fatal(err)
+	}
+	var ack message
+	if err := conn.ReadJSON(&ack); err != nil || ack.Type != "connection_ack" {
+		t.Fatalf("connection_init answered with %+v, %v", ack, err)
+	}
+	subscribe, _ := json.Marshal(map[string]string{"query": "subscription { todoCreated { text user { id } } }"})
+	if err := conn.WriteJSON(message{ID: "1", Type: "subscribe", Payload: subscribe}); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := make(chan message)
+	go func() {
+		defer close(messages)
+		for {
+			var msg message
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			messages <- msg
+		}
+	}()
+
+	// The subscription has no start acknowledgement, so todos are created
+	// until one arrives.
+	create := func() {
+		body := `{"query": "mutation { createTodo(input: {text: \"over the wire\", userId: \"alice\"}) { id } }"}`
+		req, err := http.NewRequest(http.MethodPost, ts.URL, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	create()
+	retry := time.NewTicker(50 * time.Millisecond)
+	defer retry.Stop()
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				t.Fatal("connection closed before a todo arrived")
+			}
+			if msg.Type != "next" || msg.ID != "1" {
+				t.Fatalf("unexpected message %+v", msg)
+			}
+			var payload struct {
+				Data struct {
+					TodoCreated struct {
+						Text string `json:"text"`
+						User struct {
+							ID string `json:"id"`
+						} `json:"user"`
+					} `json:"todoCreated"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+				t.Fatal(err)
+			}
+			if got := payload.Data.TodoCreated; got.Text != "over the wire" || got.User.ID != "alice" {
+				t.Errorf("received %s", msg.Payload)
+			}
+			return
+		case <-retry.C:
+			create()
+		}
+	}
+}
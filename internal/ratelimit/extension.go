@@ -0,0 +1,226 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.uber.org/zap"
+)
+
+// Policy selects the rate for a client. The first identity available wins:
+// an authenticated user, then a known API key, then the client IP. A rate
+// with zero Requests leaves that kind of client unlimited.
+type Policy struct {
+	User   Rate
+	APIKey Rate
+	IP     Rate
+	// KnownAPIKey reports whether a key earns the APIKey rate. Clients
+	// choose the header freely, so an unknown key is limited by IP as if it
+	// were absent. Nil recognises no key.
+	KnownAPIKey func(key string) bool
+	// Weight returns the number of tokens an operation costs. Nil charges
+	// one token per operation.
+	Weight func(*graphql.OperationContext) int
+}
+
+// Extension is a gqlgen handler extension that charges each operation to its
+// client's bucket and rejects it with RATE_LIMITED once the bucket is empty.
+// It must be registered after the extensions its Weight depends on.
+type Extension struct {
+	Limiter Limiter
+	Policy  Policy
+	Logger  *zap.Logger
+}
+
+var (
+	_ graphql.HandlerExtension        = Extension{}
+	_ graphql.OperationContextMutator = Extension{}
+)
+
+func (Extension) ExtensionName() string {
+	return "RateLimit"
+}
+
+func (Extension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Extension) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	c := clientFor(ctx)
+	if c == nil {
+		return nil
+	}
+	key, rate := e.Policy.keyFor(ctx, c)
+	if rate.Requests <= 0 {
+		return nil
+	}
+
+	cost := 1
+	if e.Policy.Weight != nil {
+		cost = e.Policy.Weight(opCtx)
+	}
+	res, err := e.Limiter.Allow(ctx, key, rate, cost)
+	if err != nil {
+		// A broken backend should not take the API down with it.
+		e.Logger.Warn("rate limiter unavailable", zap.Error(err))
+		return nil
+	}
+	c.record(res)
+	if res.Allowed {
+		return nil
+	}
+
+	retryAfter := retrySeconds(res.RetryAfter)
+	return &gqlerror.Error{
+		Message: "rate limit exceeded, retry in " + strconv.Itoa(retryAfter) + "s",
+		Extensions: map[string]any{
+			"code":       CodeRateLimited,
+			"retryAfter": retryAfter,
+			"cost":       cost,
+			"limit":      res.Limit,
+		},
+	}
+}
+
+func (p Policy) keyFor(ctx context.Context, c *client) (string, Rate) {
+	if claims := auth.ForContext(ctx); claims != nil {
+		return "user:" + claims.UserID(), p.User
+	}
+	if c.apiKey != "" && p.KnownAPIKey != nil && p.KnownAPIKey(c.apiKey) {
+		// Only a digest of the key is kept in memory or Redis.
+		return "apikey:" + digest(c.apiKey), p.APIKey
+	}
+	return "ip:" + c.ip, p.IP
+}
+
+// APIKeys returns a Policy.KnownAPIKey recognising exactly keys. Only
+// digests of the keys are retained.
+func APIKeys(keys []string) func(key string) bool {
+	known := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if k != "" {
+			known[digest(k)] = true
+		}
+	}
+	return func(key string) bool {
+		return known[digest(key)]
+	}
+}
+
+func digest(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// client holds what the HTTP middleware knows about the caller and the
+// outcome of the most recent limiter decision for the response.
+type client struct {
+	ip     string
+	apiKey string
+
+	mu     sync.Mutex
+	result *Result
+}
+
+func (c *client) record(res Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.result = &res
+}
+
+func (c *client) lastResult() *Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.result
+}
+
+type ctxKey struct{}
+
+func clientFor(ctx context.Context) *client {
+	c, _ := ctx.Value(ctxKey{}).(*client)
+	return c
+}
+
+// Middleware identifies the client of each request for the Extension and
+// turns a throttled operation into a 429 response with Retry-After and
+// X-RateLimit headers. The API key is read from apiKeyHeader; the client IP
+// from RemoteAddr, so install it after any real-IP middleware.
+func Middleware(apiKeyHeader string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				ip = r.RemoteAddr
+			}
+			c := &client{ip: ip}
+			if apiKeyHeader != "" {
+				c.apiKey = r.Header.Get(apiKeyHeader)
+			}
+			r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, c))
+
+			// WebSocket upgrades need the original writer to hijack the
+			// connection; throttled subscriptions still get the error.
+			if r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(&statusWriter{ResponseWriter: w, client: c}, r)
+		})
+	}
+}
+
+// statusWriter adds the limiter's headers before the response is written.
+type statusWriter struct {
+	http.ResponseWriter
+	client      *client
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if res := w.client.lastResult(); res != nil {
+		h := w.Header()
+		h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(retrySeconds(res.RetryAfter)))
+			status = http.StatusTooManyRequests
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// retrySeconds rounds d up to whole seconds, as Retry-After requires.
+func retrySeconds(d time.Duration) int {
+	return max(1, int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/executor/testexecutor"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+	"go.uber.org/zap"
+)
+
+func TestPolicyKeyFor(t *testing.T) {
+	policy := Policy{
+		User:        Rate{Requests: 1},
+		APIKey:      Rate{Requests: 2},
+		IP:          Rate{Requests: 3},
+		KnownAPIKey: APIKeys([]string{"known-key"}),
+	}
+	user := auth.WithClaims(context.Background(), &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "u1"},
+	})
+
+	for _, tc := range []struct {
+		name     string
+		ctx      context.Context
+		apiKey   string
+		wantKey  string
+		wantRate int
+	}{
+		{"user", user, "known-key", "user:u1", 1},
+		{"known key", context.Background(), "known-key", "apikey:" + digest("known-key"), 2},
+		{"unknown key", context.Background(), "made-up-key", "ip:192.0.2.1", 3},
+		{"no key", context.Background(), "", "ip:192.0.2.1", 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			key, rate := policy.keyFor(tc.ctx, &client{ip: "192.0.2.1", apiKey: tc.apiKey})
+			if key != tc.wantKey || rate.Requests != tc.wantRate {
+				t.Errorf("keyFor = %q, %d requests; want %q, %d", key, rate.Requests, tc.wantKey, tc.wantRate)
+			}
+		})
+	}
+
+	if key, _ := (Policy{}).keyFor(context.Background(), &client{ip: "192.0.2.1", apiKey: "known-key"}); key != "ip:192.0.2.1" {
+		t.Errorf("without KnownAPIKey got %q, want the IP bucket", key)
+	}
+}
+
+func TestMiddlewareLimitsUnknownAPIKeysByIP(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	srv := handler.New(testexecutor.New().Schema())
+	srv.AddTransport(transport.POST{})
+	srv.Use(Extension{
+		Limiter: NewMemory(clock),
+		Policy: Policy{
+			APIKey:      Rate{Requests: 100, Period: time.Minute},
+			IP:          Rate{Requests: 2, Period: time.Minute},
+			KnownAPIKey: APIKeys([]string{"known-key"}),
+		},
+		Logger: zap.NewNop(),
+	})
+	h := Middleware("X-API-Key")(srv)
+
+	do := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"{ name }"}`))
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", apiKey)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// A fresh made-up key per request still draws on the IP's bucket.
+	for i := range 2 {
+		if rec := do(fmt.Sprintf("random-%d", i)); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i+1, rec.Code)
+		}
+	}
+	rec := do("random-2")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request: status %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+	if !strings.Contains(rec.Body.String(), CodeRateLimited) {
+		t.Errorf("body %s lacks %s", rec.Body, CodeRateLimited)
+	}
+
+	// A configured key has its own bucket.
+	if rec := do("known-key"); rec.Code != http.StatusOK {
+		t.Errorf("known key: status %d, want 200", rec.Code)
+	}
+
+	clock.Advance(30 * time.Second)
+	if rec := do("random-3"); rec.Code != http.StatusOK {
+		t.Errorf("after refill: status %d, want 200", rec.Code)
+	}
+}
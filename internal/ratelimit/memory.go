@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many Allow calls pass between sweeps of idle buckets.
+const sweepEvery = 1024
+
+// Memory is a process-local Limiter. Buckets are lost on restart and not
+// shared between replicas; use Redis when running more than one.
+type Memory struct {
+	clock Clock
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // when the bucket will have refilled completely
+}
+
+// NewMemory returns an in-memory Limiter using clock, or the system clock
+// when clock is nil.
+func NewMemory(clock Clock) *Memory {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &Memory{clock: clock, buckets: make(map[string]*bucket)}
+}
+
+func (m *Memory) Allow(_ context.Context, key string, rate Rate, cost int) (Result, error) {
+	now := m.clock.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	if m.calls%sweepEvery == 0 {
+		m.sweep(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rate.burst()), last: now}
+		m.buckets[key] = b
+	}
+	tokens, res := take(b.tokens, b.last, now, rate, cost)
+	b.tokens, b.last = tokens, now
+	if perSecond := rate.perSecond(); perSecond > 0 {
+		missing := float64(rate.burst()) - tokens
+		b.full = now.Add(time.Duration(missing / perSecond * float64(time.Second)))
+	} else {
+		b.full = now.Add(rate.Period)
+	}
+	return res, nil
+}
+
+// sweep drops buckets that have refilled, since a new bucket is identical.
+func (m *Memory) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
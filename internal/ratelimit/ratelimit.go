@@ -0,0 +1,86 @@
+// Package ratelimit throttles GraphQL operations per client with token
+// buckets. Clients are identified by user ID, API key or IP address, each
+// with its own rate, and an operation may cost more than one token in
+// proportion to its complexity.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// CodeRateLimited is set in extensions.code when an operation is throttled.
+const CodeRateLimited = "RATE_LIMITED"
+
+// Rate is a token bucket holding up to Burst tokens and refilling Requests
+// tokens every Period.
+type Rate struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// perSecond is the refill rate in tokens per second.
+func (r Rate) perSecond() float64 {
+	if r.Period <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Period.Seconds()
+}
+
+// burst is the bucket capacity, defaulting to Requests.
+func (r Rate) burst() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	return r.Requests
+}
+
+// Result is the outcome of taking tokens from a bucket.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until enough tokens are available. It is zero
+	// when the request was allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter takes cost tokens from the bucket for key. A cost larger than the
+// burst is charged as the full burst so that no request is refused forever.
+type Limiter interface {
+	Allow(ctx context.Context, key string, rate Rate, cost int) (Result, error)
+}
+
+// Clock returns the current time. Tests substitute a fake.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// take applies the token bucket algorithm to a bucket that held tokens at
+// last, returning the new token count and the result.
+func take(tokens float64, last, now time.Time, rate Rate, cost int) (float64, Result) {
+	burst := float64(rate.burst())
+	perSecond := rate.perSecond()
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = min(burst, tokens+elapsed*perSecond)
+	}
+	need := min(float64(max(cost, 1)), burst)
+
+	res := Result{Limit: rate.burst()}
+	if tokens >= need {
+		tokens -= need
+		res.Allowed = true
+	} else if perSecond > 0 {
+		res.RetryAfter = time.Duration((need - tokens) / perSecond * float64(time.Second))
+	} else {
+		res.RetryAfter = rate.Period
+	}
+	res.Remaining = int(tokens)
+	return tokens, res
+}
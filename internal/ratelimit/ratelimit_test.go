@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// limiters returns every Limiter implementation driven by clock. The Redis
+// one runs against an in-process stand-in.
+func limiters(t *testing.T, clock Clock) map[string]Limiter {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return map[string]Limiter{
+		"memory": NewMemory(clock),
+		"redis":  NewRedis(client, "ratelimit:", clock),
+	}
+}
+
+// oneASecond refills one token per second into a bucket of three.
+var oneASecond = Rate{Requests: 60, Period: time.Minute, Burst: 3}
+
+func allow(t *testing.T, l Limiter, key string, cost int) Result {
+	t.Helper()
+	res, err := l.Allow(context.Background(), key, oneASecond, cost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestLimiterRefillsWithClock(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	for name, l := range limiters(t, clock) {
+		t.Run(name, func(t *testing.T) {
+			for i := range 3 {
+				if res := allow(t, l, name, 1); !res.Allowed || res.Remaining != 2-i {
+					t.Fatalf("request %d: %+v, want allowed with %d remaining", i+1, res, 2-i)
+				}
+			}
+			res := allow(t, l, name, 1)
+			if res.Allowed || res.RetryAfter != time.Second || res.Limit != 3 {
+				t.Fatalf("over burst: %+v, want refused with a 1s retry and limit 3", res)
+			}
+
+			clock.Advance(time.Second)
+			if res := allow(t, l, name, 1); !res.Allowed {
+				t.Fatalf("after 1s: %+v, want allowed", res)
+			}
+			if res := allow(t, l, name, 1); res.Allowed {
+				t.Fatalf("second request after 1s: %+v, want refused", res)
+			}
+
+			// A long pause refills the bucket only up to its burst.
+			clock.Advance(time.Hour)
+			for i := range 3 {
+				if res := allow(t, l, name, 1); !res.Allowed {
+					t.Fatalf("after an hour, request %d: %+v, want allowed", i+1, res)
+				}
+			}
+			if res := allow(t, l, name, 1); res.Allowed {
+				t.Fatalf("after an hour, request 4: %+v, want refused", res)
+			}
+		})
+	}
+}
+
+func TestLimiterChargesWeightCappedAtBurst(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	for name, l := range limiters(t, clock) {
+		t.Run(name, func(t *testing.T) {
+			if res := allow(t, l, name, 2); !res.Allowed || res.Remaining != 1 {
+				t.Fatalf("cost 2: %+v, want allowed with 1 remaining", res)
+			}
+			if res := allow(t, l, name, 2); res.Allowed || res.RetryAfter != time.Second {
+				t.Fatalf("cost 2 with 1 left: %+v, want refused with a 1s retry", res)
+			}
+
+			// A cost above the burst is charged as the whole burst, so it
+			// succeeds once the bucket is full again.
+			clock.Advance(3 * time.Second)
+			if res := allow(t, l, name, 10); !res.Allowed || res.Remaining != 0 {
+				t.Fatalf("cost 10 on a full bucket: %+v, want allowed with 0 remaining", res)
+			}
+			if res := allow(t, l, name, 10); res.Allowed || res.RetryAfter != 3*time.Second {
+				t.Fatalf("cost 10 on an empty bucket: %+v, want refused with a 3s retry", res)
+			}
+		})
+	}
+}
+
+func TestLimiterKeysAreIndependent(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	for name, l := range limiters(t, clock) {
+		t.Run(name, func(t *testing.T) {
+			allow(t, l, "a", 3)
+			if res := allow(t, l, "a", 1); res.Allowed {
+				t.Fatalf("a: %+v, want refused", res)
+			}
+			if res := allow(t, l, "b", 1); !res.Allowed {
+				t.Fatalf("b: %+v, want allowed", res)
+			}
+		})
+	}
+}
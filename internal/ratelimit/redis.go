@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucket refills and takes from a bucket stored as a hash of its token
+// count and last update time. The time is passed in rather than read from
+// the server so that the Limiter's Clock is authoritative.
+var tokenBucket = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+local ttl = tonumber(ARGV[5])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate)
+end
+
+local allowed = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`)
+
+// Redis is a Limiter whose buckets live in Redis and are shared by every
+// replica.
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+	clock  Clock
+}
+
+// NewRedis returns a Limiter storing buckets under keys starting with
+// prefix. A nil clock uses the system clock.
+func NewRedis(client redis.UniversalClient, prefix string, clock Clock) *Redis {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &Redis{client: client, prefix: prefix, clock: clock}
+}
+
+func (r *Redis) Allow(ctx context.Context, key string, rate Rate, cost int) (Result, error) {
+	now := r.clock.Now()
+	burst := float64(rate.burst())
+	perSecond := rate.perSecond()
+	need := min(float64(max(cost, 1)), burst)
+
+	// Keep the bucket until it would have refilled completely.
+	ttl := rate.Period
+	if perSecond > 0 {
+		ttl = time.Duration(burst / perSecond * float64(time.Second))
+	}
+	ttl += time.Second
+
+	vals, err := tokenBucket.Run(ctx, r.client, []string{r.prefix + key},
+		perSecond,
+		burst,
+		strconv.FormatFloat(float64(now.UnixMicro())/1e6, 'f', 6, 64),
+		need,
+		ttl.Milliseconds(),
+	).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	allowed, _ := vals[0].(int64)
+	tokens, _ := strconv.ParseFloat(vals[1].(string), 64)
+
+	res := Result{Allowed: allowed == 1, Limit: rate.burst(), Remaining: int(math.Floor(tokens))}
+	if !res.Allowed {
+		if perSecond > 0 {
+			res.RetryAfter = time.Duration((need - tokens) / perSecond * float64(time.Second))
+		} else {
+			res.RetryAfter = rate.Period
+		}
+	}
+	return res, nil
+}
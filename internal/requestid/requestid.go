@@ -0,0 +1,62 @@
+// Package requestid assigns every HTTP request an ID that is propagated
+// through its context, echoed in the response and attached to log lines.
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// DefaultHeader is the header used when none is configured.
+const DefaultHeader = "X-Request-ID"
+
+// maxLength bounds client-supplied IDs so they cannot bloat log lines.
+const maxLength = 128
+
+type ctxKey struct{}
+
+// Middleware accepts the client's request ID from header when it is a short
+// printable string and generates a UUID otherwise. The ID is stored on the
+// request context, set on the request header for handlers that read it from
+// there, and echoed in the response header.
+func Middleware(header string) func(http.Handler) http.Handler {
+	if header == "" {
+		header = DefaultHeader
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(header)
+			if !valid(id) {
+				id = uuid.NewString()
+				r.Header.Set(header, id)
+			}
+			w.Header().Set(header, id)
+			next.ServeHTTP(w, r.WithContext(WithID(r.Context(), id)))
+		})
+	}
+}
+
+// WithID returns a copy of ctx carrying id.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request ID stored on ctx, or "".
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
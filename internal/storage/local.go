@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalOptions configures storage on local disk.
+type LocalOptions struct {
+	Dir string
+	// URLPrefix is where Handler is mounted, such as "/files/".
+	URLPrefix string
+	// URLSecret signs download URLs. When empty a random secret is used,
+	// so URLs stop working when the process restarts.
+	URLSecret string
+	// URLExpiry is how long signed download URLs stay valid.
+	URLExpiry time.Duration
+}
+
+// Local stores files in a directory and serves them under a URL prefix.
+// Like presigned S3 URLs, its download URLs are signed and expire, so only
+// clients that were shown an attachment can fetch it.
+type Local struct {
+	dir     string
+	staging string // holds uploads in progress, outside the served dir
+	prefix  string
+	secret  []byte
+	expiry  time.Duration
+	now     func() time.Time
+}
+
+var _ Storage = (*Local)(nil)
+
+// NewLocal returns a Local storing files under opts.Dir. Uploads are staged
+// in the sibling directory "<dir>.tmp" so that Handler never serves a
+// partial file; it must be on the same filesystem as dir, so mount a volume
+// above dir rather than at it. Both directories are created if needed.
+func NewLocal(opts LocalOptions) (*Local, error) {
+	dir := filepath.Clean(opts.Dir)
+	staging := dir + ".tmp"
+	for _, d := range []string{dir, staging} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	prefix := opts.URLPrefix
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	secret := []byte(opts.URLSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+	expiry := opts.URLExpiry
+	if expiry <= 0 {
+		expiry = 15 * time.Minute
+	}
+	return &Local{dir: dir, staging: staging, prefix: prefix, secret: secret, expiry: expiry, now: time.Now}, nil
+}
+
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a staged file first so a failed upload never leaves a
+	// truncated file behind under the final name.
+	tmp, err := os.CreateTemp(l.staging, "upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// URL returns a download URL for key that Handler accepts until it
+// expires.
+func (l *Local) URL(ctx context.Context, key string) (string, error) {
+	expires := strconv.FormatInt(l.now().Add(l.expiry).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {l.sign(key, expires)}}
+	return l.prefix + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+func (l *Local) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify reports whether r carries an unexpired signature for key.
+func (l *Local) verify(key string, r *http.Request) bool {
+	expires := r.URL.Query().Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || l.now().Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(r.URL.Query().Get("signature")), []byte(l.sign(key, expires)))
+}
+
+// Prefix is the URL path Handler must be mounted under.
+func (l *Local) Prefix() string {
+	return l.prefix
+}
+
+// Handler serves stored files as downloads to requests bearing a URL from
+// URL that has not expired; others get 403. The content type is sniffed
+// again on the way out and never rendered inline, so an uploaded HTML or
+// SVG file cannot run script on the API's origin.
+func (l *Local) Handler() http.Handler {
+	files := http.StripPrefix(l.prefix, http.FileServer(http.Dir(l.dir)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutPrefix(r.URL.Path, l.prefix)
+		if !ok || strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		if !l.verify(key, r) {
+			http.Error(w, "invalid or expired file URL", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Disposition", "attachment")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		files.ServeHTTP(w, r)
+	})
+}
+
+// path maps key into the storage directory, refusing keys that escape it.
+func (l *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", errors.New("storage: invalid key " + key)
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newLocal(t *testing.T) (*Local, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "uploads")
+	l, err := NewLocal(LocalOptions{Dir: dir, URLPrefix: "/files", URLSecret: "local-test-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, dir
+}
+
+func TestLocalPutURLDelete(t *testing.T) {
+	ctx := context.Background()
+	l, dir := newLocal(t)
+
+	const key = "todos/1/a report.txt"
+	if err := l.Put(ctx, key, strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "todos", "1", "a report.txt"))
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("stored %q, %v; want hello", data, err)
+	}
+
+	u, err := l.URL(ctx, key)
+	if err != nil || !strings.HasPrefix(u, "/files/todos/1/a%20report.txt?") {
+		t.Fatalf("URL = %q, %v", u, err)
+	}
+	rec := httptest.NewRecorder()
+	l.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u, nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "hello" {
+		t.Fatalf("GET %s: %d %q", u, rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != "attachment" {
+		t.Errorf("Content-Disposition = %q, want attachment", got)
+	}
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+	}
+
+	if err := l.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "todos", "1", "a report.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file survived Delete: %v", err)
+	}
+	if err := l.Delete(ctx, key); err != nil {
+		t.Errorf("deleting a missing file: %v", err)
+	}
+}
+
+func TestLocalRejectsTraversal(t *testing.T) {
+	ctx := context.Background()
+	l, dir := newLocal(t)
+
+	for _, key := range []string{"", "/", "../escape", "a/../../escape", "a/./b", "/abs", "a//b", "a/"} {
+		if err := l.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		if err := l.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) succeeded", key)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("a key escaped the storage directory: %v", err)
+	}
+}
+
+// blockingReader yields some content, then waits until released.
+type blockingReader struct {
+	sent    bool
+	release chan struct{}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	if !r.sent {
+		r.sent = true
+		return copy(p, "partial"), nil
+	}
+	<-r.release
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestLocalStagesUploadsOutsideServedDir(t *testing.T) {
+	ctx := context.Background()
+	l, dir := newLocal(t)
+
+	r := &blockingReader{release: make(chan struct{})}
+	done := make(chan error)
+	go func() { done <- l.Put(ctx, "todos/1/big.bin", r, 100, "application/octet-stream") }()
+
+	// Wait for the upload to be staged.
+	staged := func() []string {
+		names, _ := filepath.Glob(filepath.Join(dir+".tmp", "*"))
+		return names
+	}
+	for len(staged()) == 0 {
+		select {
+		case err := <-done:
+			t.Fatalf("Put returned early: %v", err)
+		default:
+		}
+	}
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			t.Errorf("partial upload %s is inside the served directory", p)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	close(r.release)
+	if err := <-done; err == nil {
+		t.Fatal("Put succeeded on a failed read")
+	}
+	if names := staged(); len(names) != 0 {
+		t.Errorf("failed upload left %v behind", names)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "todos", "1", "big.bin")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("failed upload was stored: %v", err)
+	}
+}
+
+func TestLocalURLsAreSignedAndExpire(t *testing.T) {
+	ctx := context.Background()
+	l, _ := newLocal(t)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	for _, key := range []string{"todos/1/a.txt", "todos/2/b.txt"} {
+		if err := l.Put(ctx, key, strings.NewReader("secret"), 6, "text/plain"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	signed, err := l.URL(ctx, "todos/1/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewLocal(LocalOptions{Dir: t.TempDir(), URLPrefix: "/files"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := other.URL(ctx, "todos/1/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(u string) int {
+		rec := httptest.NewRecorder()
+		l.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u, nil))
+		return rec.Code
+	}
+	_, query, _ := strings.Cut(signed, "?")
+	for _, tt := range []struct {
+		name string
+		url  string
+		want int
+	}{
+		{"signed", signed, http.StatusOK},
+		{"unsigned", "/files/todos/1/a.txt", http.StatusForbidden},
+		{"another key's signature", "/files/todos/2/b.txt?" + query, http.StatusForbidden},
+		{"tampered expiry", strings.Replace(signed, "expires=", "expires=9", 1), http.StatusForbidden},
+		{"signed with another secret", foreign, http.StatusForbidden},
+	} {
+		if got := get(tt.url); got != tt.want {
+			t.Errorf("%s: GET %s = %d, want %d", tt.name, tt.url, got, tt.want)
+		}
+	}
+
+	now = now.Add(l.expiry - time.Second)
+	if got := get(signed); got != http.StatusOK {
+		t.Errorf("before expiry: %d", got)
+	}
+	now = now.Add(2 * time.Second)
+	if got := get(signed); got != http.StatusForbidden {
+		t.Errorf("after expiry: %d, want %d", got, http.StatusForbidden)
+	}
+}
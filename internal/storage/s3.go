@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Options configures an S3-compatible object store such as AWS S3, MinIO
+// or Ceph.
+type S3Options struct {
+	Endpoint  string // host[:port], without a scheme
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	// URLExpiry is how long presigned download URLs stay valid.
+	URLExpiry time.Duration
+}
+
+// S3 stores files as objects in a bucket and hands out presigned URLs.
+type S3 struct {
+	client *minio.Client
+	bucket string
+	expiry time.Duration
+}
+
+var _ Storage = (*S3)(nil)
+
+// NewS3 connects to the object store described by opts. The bucket must
+// already exist.
+func NewS3(opts S3Options) (*S3, error) {
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure: opts.UseSSL,
+		Region: opts.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3 client: %w", err)
+	}
+	expiry := opts.URLExpiry
+	if expiry <= 0 {
+		expiry = 15 * time.Minute
+	}
+	return &S3{client: client, bucket: opts.Bucket, expiry: expiry}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:        contentType,
+		ContentDisposition: "attachment",
+	})
+	return err
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3) URL(ctx context.Context, key string) (string, error) {
+	params := url.Values{}
+	params.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%q", path.Base(key)))
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, s.expiry, params)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// Ping checks that the bucket is reachable.
+func (s *S3) Ping(ctx context.Context) error {
+	ok, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("bucket %q does not exist", s.bucket)
+	}
+	return nil
+}
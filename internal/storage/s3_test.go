@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+)
+
+// newS3 returns an S3 storage against an in-process S3 stand-in.
+func newS3(t *testing.T, bucket string) (*S3, *s3mem.Backend) {
+	t.Helper()
+	backend := s3mem.New()
+	if err := backend.CreateBucket("uploads"); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(gofakes3.New(backend).Server())
+	t.Cleanup(ts.Close)
+
+	s, err := NewS3(S3Options{
+		Endpoint:  strings.TrimPrefix(ts.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    bucket,
+		AccessKey: "access",
+		SecretKey: "secret",
+		URLExpiry: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, backend
+}
+
+func TestS3PutURLDelete(t *testing.T) {
+	ctx := context.Background()
+	s, backend := newS3(t, "uploads")
+
+	const key = "todos/1/report.pdf"
+	if err := s.Put(ctx, key, strings.NewReader("%PDF-1.7"), 8, "application/pdf"); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := backend.GetObject("uploads", key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Contents.Close()
+	if got := obj.Metadata["Content-Type"]; got != "application/pdf" {
+		t.Errorf("stored Content-Type %q, want application/pdf", got)
+	}
+	if got := obj.Metadata["Content-Disposition"]; got != "attachment" {
+		t.Errorf("stored Content-Disposition %q, want attachment", got)
+	}
+
+	raw, err := s.URL(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("X-Amz-Expires") != "60" || q.Get("response-content-disposition") != `attachment; filename="report.pdf"` {
+		t.Errorf("presigned URL %s lacks the expiry or download disposition", raw)
+	}
+	resp, err := http.Get(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "%PDF-1.7" {
+		t.Errorf("GET presigned URL: %d %q", resp.StatusCode, body)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.HeadObject("uploads", key); !gofakes3.HasErrorCode(err, gofakes3.ErrNoSuchKey) {
+		t.Errorf("object survived Delete: %v", err)
+	}
+}
+
+func TestS3Ping(t *testing.T) {
+	ctx := context.Background()
+	if s, _ := newS3(t, "uploads"); s.Ping(ctx) != nil {
+		t.Errorf("Ping on an existing bucket: %v", s.Ping(ctx))
+	}
+	if s, _ := newS3(t, "missing"); s.Ping(ctx) == nil {
+		t.Error("Ping succeeded on a missing bucket")
+	}
+}
@@ -0,0 +1,72 @@
+// Package storage keeps uploaded files on local disk or in an S3-compatible
+// object store.
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ErrTypeNotAllowed is returned by Sniff for content outside the allowlist.
+var ErrTypeNotAllowed = errors.New("storage: file type not allowed")
+
+// Storage stores blobs under slash-separated keys.
+type Storage interface {
+	// Put stores size bytes from r under key.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Delete(ctx context.Context, key string) error
+	// URL returns where clients can download key.
+	URL(ctx context.Context, key string) (string, error)
+}
+
+// scriptable lists types a browser may run script from. Sniff refuses them
+// whatever the allowlist says.
+var scriptable = []string{"text/html", "image/svg+xml"}
+
+// Sniff detects the content type of r from its first 512 bytes and checks it
+// against allowed, which holds MIME types or prefixes such as "image/*". The
+// returned reader yields the full content including the sniffed bytes.
+// The client-declared content type is deliberately ignored.
+func Sniff(r io.Reader, allowed []string) (string, io.Reader, error) {
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", nil, err
+	}
+
+	contentType := http.DetectContentType(head)
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	// http.DetectContentType has no SVG signature and reports it as plain
+	// text or XML.
+	if strings.HasPrefix(mediaType, "text/") && bytes.Contains(bytes.ToLower(head), []byte("<svg")) {
+		mediaType = "image/svg+xml"
+	}
+	if slices.Contains(scriptable, mediaType) || !Allowed(mediaType, allowed) {
+		return "", nil, fmt.Errorf("%w: %s", ErrTypeNotAllowed, mediaType)
+	}
+	return contentType, br, nil
+}
+
+// Allowed reports whether mediaType matches an entry of allowed.
+func Allowed(mediaType string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+var defaultAllowed = []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"}
+
+func TestAllowed(t *testing.T) {
+	allowed := []string{"image/*", "application/pdf"}
+	for mediaType, want := range map[string]bool{
+		"image/png":       true,
+		"image/webp":      true,
+		"application/pdf": true,
+		"application/zip": false,
+		"imagex/png":      false,
+		"text/plain":      false,
+	} {
+		if got := Allowed(mediaType, allowed); got != want {
+			t.Errorf("Allowed(%q) = %v, want %v", mediaType, got, want)
+		}
+	}
+}
+
+func TestSniff(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 600)
+	for _, tc := range []struct {
+		name     string
+		content  string
+		allowed  []string
+		wantType string // empty when refused
+	}{
+		{"png", png, defaultAllowed, "image/png"},
+		{"pdf", "%PDF-1.7\n", defaultAllowed, "application/pdf"},
+		{"text", "hello", defaultAllowed, "text/plain; charset=utf-8"},
+		{"zip", "PK\x03\x04rest", defaultAllowed, ""},
+		{"html", "<!DOCTYPE html><script>alert(1)</script>", defaultAllowed, ""},
+		{"html allowed explicitly", "<html><script>alert(1)</script>", []string{"text/*"}, ""},
+		{"svg as text", `<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`, defaultAllowed, ""},
+		{"svg as xml", `<?xml version="1.0"?><SVG xmlns="http://www.w3.org/2000/svg"/>`, []string{"text/*", "image/*"}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			contentType, r, err := Sniff(strings.NewReader(tc.content), tc.allowed)
+			if tc.wantType == "" {
+				if !errors.Is(err, ErrTypeNotAllowed) {
+					t.Fatalf("Sniff = %q, %v; want ErrTypeNotAllowed", contentType, err)
+				}
+				return
+			}
+			if err != nil || contentType != tc.wantType {
+				t.Fatalf("Sniff = %q, %v; want %q", contentType, err, tc.wantType)
+			}
+			// The sniffed bytes are not lost.
+			if body, _ := io.ReadAll(r); string(body) != tc.content {
+				t.Errorf("reader yields %d bytes, want %d", len(body), len(tc.content))
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+)
+
+// Memory is a Store that keeps everything in process memory. Records are
+// copied on the way in and out so callers never share state with the store.
+type Memory struct {
+	mu    sync.RWMutex
+	todos map[string]*model.Todo
+	order []string
+	users map[string]*model.User
+	uids  []string
+	// emails maps a lower-cased email to its user ID.
+	emails map[string]string
+
+	tokens map[string]*RefreshToken
+	hashes map[string]string
+
+	attachments []*model.Attachment
+}
+
+var _ Store = (*Memory)(nil)
+
+// NewMemory returns an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{
+		todos:  make(map[string]*model.Todo),
+		users:  make(map[string]*model.User),
+		emails: make(map[string]string),
+		tokens: make(map[string]*RefreshToken),
+		hashes: make(map[string]string),
+	}
+}
+
+func (m *Memory) Todos(ctx context.Context) ([]*model.Todo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	todos := make([]*model.Todo, 0, len(m.order))
+	for _, id := range m.order {
+		todos = append(todos, copyTodo(m.todos[id]))
+	}
+	return todos, nil
+}
+
+func (m *Memory) Todo(ctx context.Context, id string) (*model.Todo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	todo, ok := m.todos[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyTodo(todo), nil
+}
+
+func (m *Memory) TodosByIDs(ctx context.Context, ids []string) ([]*model.Todo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	todos := make([]*model.Todo, 0, len(ids))
+	for _, id := range ids {
+		if todo, ok := m.todos[id]; ok {
+			todos = append(todos, copyTodo(todo))
+		}
+	}
+	return todos, nil
+}
+
+func (m *Memory) TodosByUserIDs(ctx context.Context, userIDs []string) ([]*model.Todo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		wanted[id] = true
+	}
+
+	var todos []*model.Todo
+	for _, id := range m.order {
+		if todo := m.todos[id]; wanted[todo.UserID] {
+			todos = append(todos, copyTodo(todo))
+		}
+	}
+	return todos, nil
+}
+
+// CreateTodo stores todo, assigning it an ID when it has none.
+func (m *Memory) CreateTodo(ctx context.Context, todo *model.Todo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if todo.ID == "" {
+		todo.ID = uuid.NewString()
+	}
+	now := time.Now()
+	todo.CreatedAt, todo.UpdatedAt = now, now
+	m.todos[todo.ID] = copyTodo(todo)
+	m.order = append(m.order, todo.ID)
+	return nil
+}
+
+func (m *Memory) UpdateTodo(ctx context.Context, todo *model.Todo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.todos[todo.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	todo.CreatedAt = existing.CreatedAt
+	todo.UpdatedAt = time.Now()
+	m.todos[todo.ID] = copyTodo(todo)
+	return nil
+}
+
+func (m *Memory) DeleteTodo(ctx context.Context, id string) (*model.Todo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	todo, ok := m.todos[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	delete(m.todos, id)
+	for i, existing := range m.order {
+		if existing == id {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+	m.attachments = slices.DeleteFunc(m.attachments, func(a *model.Attachment) bool {
+		return a.TodoID == id
+	})
+	return todo, nil
+}
+
+func (m *Memory) Users(ctx context.Context) ([]*model.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := make([]*model.User, 0, len(m.uids))
+	for _, id := range m.uids {
+		users = append(users, copyUser(m.users[id]))
+	}
+	return users, nil
+}
+
+func (m *Memory) User(ctx context.Context, id string) (*model.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyUser(user), nil
+}
+
+func (m *Memory) UsersByIDs(ctx context.Context, ids []string) ([]*model.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := make([]*model.User, 0, len(ids))
+	for _, id := range ids {
+		if user, ok := m.users[id]; ok {
+			users = append(users, copyUser(user))
+		}
+	}
+	return users, nil
+}
+
+func (m *Memory) UserByEmail(ctx context.Context, email string) (*model.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.emails[strings.ToLower(email)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyUser(m.users[id]), nil
+}
+
+// CreateUser stores user, assigning it an ID when it has none.
+func (m *Memory) CreateUser(ctx context.Context, user *model.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	email := strings.ToLower(user.Email)
+	if email != "" {
+		if _, taken := m.emails[email]; taken {
+			return ErrConflict
+		}
+	}
+	if user.ID == "" {
+		user.ID = uuid.NewString()
+	}
+	if _, ok := m.users[user.ID]; !ok {
+		m.uids = append(m.uids, user.ID)
+	}
+	m.users[user.ID] = copyUser(user)
+	if email != "" {
+		m.emails[email] = user.ID
+	}
+	return nil
+}
+
+// CreateRefreshToken stores token, assigning it an ID when it has none.
+func (m *Memory) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, taken := m.hashes[token.TokenHash]; taken {
+		return ErrConflict
+	}
+	if token.ID == "" {
+		token.ID = uuid.NewString()
+	}
+	c := *token
+	m.tokens[token.ID] = &c
+	m.hashes[token.TokenHash] = token.ID
+	return nil
+}
+
+func (m *Memory) RefreshTokenByHash(ctx context.Context, hash string) (*RefreshToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.hashes[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *m.tokens[id]
+	return &c, nil
+}
+
+func (m *Memory) RevokeRefreshToken(ctx context.Context, id, replacedBy string, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, ok := m.tokens[id]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if token.RevokedAt != nil {
+		return false, nil
+	}
+	token.RevokedAt = &at
+	token.ReplacedBy = replacedBy
+	return true, nil
+}
+
+func (m *Memory) RevokeRefreshTokenFamily(ctx context.Context, familyID string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, token := range m.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (m *Memory) CreateAttachment(ctx context.Context, attachment *model.Attachment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.todos[attachment.TodoID]; !ok {
+		return ErrNotFound
+	}
+	if attachment.ID == "" {
+		attachment.ID = uuid.NewString()
+	}
+	if attachment.CreatedAt.IsZero() {
+		attachment.CreatedAt = time.Now()
+	}
+	c := *attachment
+	m.attachments = append(m.attachments, &c)
+	return nil
+}
+
+func (m *Memory) AttachmentsByTodoIDs(ctx context.Context, todoIDs []string) ([]*model.Attachment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var attachments []*model.Attachment
+	for _, a := range m.attachments {
+		if slices.Contains(todoIDs, a.TodoID) {
+			c := *a
+			attachments = append(attachments, &c)
+		}
+	}
+	return attachments, nil
+}
+
+// Ping always succeeds; the data lives in process.
+func (m *Memory) Ping(context.Context) error {
+	return nil
+}
+
+// Close is a no-op; there are no connections to release.
+func (m *Memory) Close() error {
+	return nil
+}
+
+func copyTodo(todo *model.Todo) *model.Todo {
+	c := *todo
+	if todo.DueAt != nil {
+		due := *todo.DueAt
+		c.DueAt = &due
+	}
+	return &c
+}
+
+func copyUser(user *model.User) *model.User {
+	c := *user
+	c.Roles = slices.Clone(user.Roles)
+	return &c
+}
@@ -0,0 +1,94 @@
+// Package store defines the persistence interfaces used by the resolvers and
+// an in-memory implementation suitable for development.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("store: not found")
+	// ErrConflict is returned when a write would violate a uniqueness
+	// constraint.
+	ErrConflict = errors.New("store: conflict")
+)
+
+// Store is the full set of persistence operations the resolvers depend on.
+type Store interface {
+	TodoStore
+	UserStore
+	RefreshTokenStore
+	AttachmentStore
+	// Ping reports whether the backing database is reachable.
+	Ping(ctx context.Context) error
+	// Close releases the underlying connections.
+	Close() error
+}
+
+// TodoStore persists todos.
+type TodoStore interface {
+	Todos(ctx context.Context) ([]*model.Todo, error)
+	Todo(ctx context.Context, id string) (*model.Todo, error)
+	// TodosByIDs returns the todos that exist among ids, in no particular
+	// order. Missing IDs are omitted rather than reported as errors.
+	TodosByIDs(ctx context.Context, ids []string) ([]*model.Todo, error)
+	// TodosByUserIDs returns every todo owned by any of userIDs, oldest
+	// first.
+	TodosByUserIDs(ctx context.Context, userIDs []string) ([]*model.Todo, error)
+	// CreateTodo sets the ID when empty, and CreatedAt and UpdatedAt.
+	CreateTodo(ctx context.Context, todo *model.Todo) error
+	// UpdateTodo sets UpdatedAt and keeps the stored CreatedAt.
+	UpdateTodo(ctx context.Context, todo *model.Todo) error
+	// DeleteTodo also deletes the todo's attachment records.
+	DeleteTodo(ctx context.Context, id string) (*model.Todo, error)
+}
+
+// AttachmentStore persists the records of files attached to todos.
+type AttachmentStore interface {
+	CreateAttachment(ctx context.Context, attachment *model.Attachment) error
+	// AttachmentsByTodoIDs returns the attachments of any of todoIDs,
+	// oldest first.
+	AttachmentsByTodoIDs(ctx context.Context, todoIDs []string) ([]*model.Attachment, error)
+}
+
+// UserStore persists users.
+type UserStore interface {
+	Users(ctx context.Context) ([]*model.User, error)
+	User(ctx context.Context, id string) (*model.User, error)
+	// UsersByIDs returns the users that exist among ids, in no particular
+	// order. Missing IDs are omitted rather than reported as errors.
+	UsersByIDs(ctx context.Context, ids []string) ([]*model.User, error)
+	UserByEmail(ctx context.Context, email string) (*model.User, error)
+	// CreateUser returns ErrConflict if the email is already registered.
+	CreateUser(ctx context.Context, user *model.User) error
+}
+
+// RefreshToken is a stored refresh token. Only a hash of the token is kept.
+// Tokens issued by rotating one another share a FamilyID so that a reused
+// token can revoke every descendant.
+type RefreshToken struct {
+	ID         string
+	FamilyID   string
+	UserID     string
+	TokenHash  string
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy string
+}
+
+// RefreshTokenStore persists refresh tokens.
+type RefreshTokenStore interface {
+	CreateRefreshToken(ctx context.Context, token *RefreshToken) error
+	RefreshTokenByHash(ctx context.Context, hash string) (*RefreshToken, error)
+	// RevokeRefreshToken marks a token revoked and records its replacement.
+	// It reports false if the token had already been revoked, which callers
+	// treat as reuse.
+	RevokeRefreshToken(ctx context.Context, id, replacedBy string, at time.Time) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string, at time.Time) error
+}
@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/natnael_wondwoesn/GGStarter/internal/tracing"
+
+// Extension starts a root span per operation, continuing any trace passed in
+// the traceparent header, with child spans for parsing, validation and
+// resolvers. The trace ID, when a tracer provider is installed, is added to
+// every error's extensions.
+//
+// With SkipTrivialFields only fields backed by resolver functions get spans;
+// plain struct field reads are skipped.
+type Extension struct {
+	SkipTrivialFields bool
+}
+
+var (
+	_ graphql.HandlerExtension     = Extension{}
+	_ graphql.OperationInterceptor = Extension{}
+	_ graphql.FieldInterceptor     = Extension{}
+)
+
+func (Extension) ExtensionName() string {
+	return "OpenTelemetry"
+}
+
+func (Extension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	opCtx := graphql.GetOperationContext(ctx)
+	tracer := otel.Tracer(tracerName)
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(opCtx.Headers))
+
+	name, opType := operationName(opCtx)
+	ctx, span := tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithTimestamp(opCtx.Stats.OperationStart),
+		trace.WithAttributes(
+			attribute.String("graphql.operation.name", opCtx.OperationName),
+			attribute.String("graphql.operation.type", opType),
+		),
+	)
+
+	// Parsing and validation finished before extensions run, so their spans
+	// are recorded retroactively from the timings gqlgen collected.
+	stats := opCtx.Stats
+	for _, phase := range []struct {
+		name   string
+		timing graphql.TraceTiming
+	}{
+		{"graphql.parse", stats.Parsing},
+		{"graphql.validate", stats.Validation},
+	} {
+		if phase.timing.Start.IsZero() {
+			continue
+		}
+		_, child := tracer.Start(ctx, phase.name, trace.WithTimestamp(phase.timing.Start))
+		child.End(trace.WithTimestamp(phase.timing.End))
+	}
+
+	var once sync.Once
+	end := func() { once.Do(func() { span.End() }) }
+	isSubscription := opCtx.Operation != nil && opCtx.Operation.Operation == ast.Subscription
+
+	handler := next(ctx)
+	return func(respCtx context.Context) *graphql.Response {
+		resp := handler(trace.ContextWithSpan(respCtx, span))
+		if resp == nil {
+			end()
+			return nil
+		}
+
+		// Without a tracer provider the span context is empty and its
+		// all-zero trace ID would lead nowhere.
+		if sc := span.SpanContext(); sc.IsValid() {
+			traceID := sc.TraceID().String()
+			for _, err := range resp.Errors {
+				if err.Extensions == nil {
+					err.Extensions = map[string]any{}
+				}
+				err.Extensions["traceId"] = traceID
+			}
+		}
+		if len(resp.Errors) > 0 {
+			span.SetStatus(codes.Error, resp.Errors.Error())
+		}
+		if !isSubscription {
+			end()
+		}
+		return resp
+	}
+}
+
+func (e Extension) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || (e.SkipTrivialFields && !fc.IsResolver) {
+		return next(ctx)
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fc.Object+"."+fc.Field.Name,
+		trace.WithAttributes(
+			attribute.String("graphql.field.path", fc.Path().String()),
+			attribute.String("graphql.field.name", fc.Field.Name),
+			attribute.String("graphql.field.type", fc.Field.Definition.Type.String()),
+		),
+	)
+	defer span.End()
+
+	res, err := next(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return res, err
+}
+
+func operationName(opCtx *graphql.OperationContext) (name, opType string) {
+	name = opCtx.OperationName
+	if opCtx.Operation != nil {
+		opType = string(opCtx.Operation.Operation)
+		if name == "" {
+			name = opCtx.Operation.Name
+		}
+	}
+	if name == "" {
+		name = "anonymous"
+	}
+	return opType + " " + name, opType
+}
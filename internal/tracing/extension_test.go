@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/executor/testexecutor"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// setProvider installs tp globally for the duration of the test.
+func setProvider(t *testing.T, tp trace.TracerProvider) {
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+}
+
+type response struct {
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+// query runs a failing query through the extension.
+func query(t *testing.T, header http.Header) response {
+	srv := handler.New(testexecutor.NewError().Schema())
+	srv.AddTransport(transport.POST{})
+	srv.Use(Extension{})
+
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query Name { name }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body, err)
+	}
+	if len(resp.Errors) != 1 {
+		t.Fatalf("got errors %+v, want the resolver error", resp.Errors)
+	}
+	return resp
+}
+
+func TestExtensionRecordsSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	setProvider(t, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	resp := query(t, http.Header{"Traceparent": {parent}})
+
+	spans := recorder.Ended()
+	byName := make(map[string]sdktrace.ReadOnlySpan, len(spans))
+	for _, s := range spans {
+		byName[s.Name()] = s
+	}
+	root, ok := byName["query Name"]
+	if !ok {
+		t.Fatalf("no operation span among %v", names(spans))
+	}
+	if got := root.SpanKind(); got != trace.SpanKindServer {
+		t.Errorf("operation span kind %v, want server", got)
+	}
+	traceID := root.SpanContext().TraceID().String()
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace %s does not continue the traceparent header", traceID)
+	}
+	for _, phase := range []string{"graphql.parse", "graphql.validate"} {
+		s, ok := byName[phase]
+		if !ok {
+			t.Errorf("no %s span among %v", phase, names(spans))
+			continue
+		}
+		if s.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("%s is not a child of the operation span", phase)
+		}
+	}
+
+	if got := resp.Errors[0].Extensions["traceId"]; got != traceID {
+		t.Errorf("error traceId = %v, want %s", got, traceID)
+	}
+}
+
+func TestExtensionOmitsTraceIDWithoutProvider(t *testing.T) {
+	setProvider(t, noop.NewTracerProvider())
+
+	resp := query(t, nil)
+
+	if got, ok := resp.Errors[0].Extensions["traceId"]; ok {
+		t.Errorf("error has traceId %v without a tracer provider", got)
+	}
+}
+
+func names(spans []sdktrace.ReadOnlySpan) []string {
+	var n []string
+	for _, s := range spans {
+		n = append(n, s.Name())
+	}
+	return n
+}
@@ -1,43 +1,384 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
 
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
 	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
-	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/gorilla/websocket"
+	"github.com/natnael_wondwoesn/GGStarter/config"
 	"github.com/natnael_wondwoesn/GGStarter/graph"
+	"github.com/natnael_wondwoesn/GGStarter/graph/cachecontrol"
+	"github.com/natnael_wondwoesn/GGStarter/graph/constraint"
+	"github.com/natnael_wondwoesn/GGStarter/graph/dataloader"
+	"github.com/natnael_wondwoesn/GGStarter/graph/limits"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/graph/oplog"
+	"github.com/natnael_wondwoesn/GGStarter/graph/persisted"
+	"github.com/natnael_wondwoesn/GGStarter/internal/apperr"
+	"github.com/natnael_wondwoesn/GGStarter/internal/auth"
+	"github.com/natnael_wondwoesn/GGStarter/internal/cache"
+	"github.com/natnael_wondwoesn/GGStarter/internal/health"
+	"github.com/natnael_wondwoesn/GGStarter/internal/httpserver"
+	"github.com/natnael_wondwoesn/GGStarter/internal/ide"
+	"github.com/natnael_wondwoesn/GGStarter/internal/logging"
+	"github.com/natnael_wondwoesn/GGStarter/internal/metrics"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/ratelimit"
+	"github.com/natnael_wondwoesn/GGStarter/internal/storage"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+	"github.com/natnael_wondwoesn/GGStarter/internal/tracing"
+	"github.com/redis/go-redis/v9"
 	"github.com/vektah/gqlparser/v2/ast"
+	"go.uber.org/zap"
 )
 
-const defaultPort = "8080"
-
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	cfg, err := config.LoadConfig(".")
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	logger, err := logging.New(cfg.Server.Mode)
+	if err != nil {
+		log.Fatalf("create logger: %v", err)
+	}
+	defer logger.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		logger.Fatal("configure tracing", zap.Error(err))
 	}
 
-	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
+	verifier, err := auth.NewVerifier(cfg.JWT)
+	if err != nil {
+		logger.Fatal("configure jwt", zap.Error(err))
+	}
+
+	// The Redis client is created on first use by a component configured
+	// with the redis backend.
+	var rdb *redis.Client
+	redisClient := func() *redis.Client {
+		if rdb == nil {
+			rdb = redis.NewClient(&redis.Options{
+				Addr:     cfg.Redis.Addr,
+				Password: cfg.Redis.Password,
+				DB:       cfg.Redis.DB,
+			})
+		}
+		return rdb
+	}
+
+	files, err := fileStorage(cfg.Uploads)
+	if err != nil {
+		logger.Fatal("configure file storage", zap.Error(err))
+	}
+
+	events := pubsub.NewHub[*model.Todo]()
+
+	db := store.NewMemory()
+	authService := auth.NewService(db, cfg.JWT)
+	if admin := cfg.BootstrapAdmin; admin.Email != "" {
+		user, created, err := authService.EnsureAdmin(ctx, admin.Name, admin.Email, admin.Password)
+		if err != nil {
+			logger.Fatal("create bootstrap admin", zap.Error(err))
+		}
+		logger.Info("bootstrap admin",
+			zap.String("user_id", user.ID),
+			zap.Bool("created", created),
+		)
+	}
+	resolver := &graph.Resolver{
+		Store:            db,
+		Events:           events,
+		Auth:             authService,
+		Files:            files,
+		AllowedFileTypes: cfg.Uploads.AllowedTypes,
+	}
+	gqlConfig := graph.Config{Resolvers: resolver}
+	gqlConfig.Directives.Auth = graph.Auth
+	gqlConfig.Directives.HasRole = graph.HasRole
+	graph.SetComplexity(&gqlConfig.Complexity)
+	schema := graph.NewExecutableSchema(gqlConfig)
+	srv := handler.New(schema)
+	srv.SetErrorPresenter(apperr.Presenter(logger, cfg.Server.Mode == "production"))
+	srv.SetRecoverFunc(apperr.Recover(logger))
 
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})
 	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{
+		MaxUploadSize: cfg.Uploads.MaxSize,
+		MaxMemory:     cfg.Uploads.MaxMemory,
+	})
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		InitTimeout:           10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			// Both the legacy subscriptions-transport-ws protocol and the
+			// newer graphql-ws library protocol are negotiated.
+			Subprotocols: []string{"graphql-transport-ws", "graphql-ws"},
+			CheckOrigin:  httpserver.WebsocketOrigin(cfg.HTTP.CORS),
+		},
+		// Browsers cannot set headers on a WebSocket upgrade, so credentials
+		// travel in the connection_init payload instead.
+		InitFunc: auth.WebsocketInit(verifier),
+	})
 
-	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	// Only query text is shared through Redis, by APQ. Every replica
+	// validates the documents it runs, so Redis cannot vouch for one.
+	queryCache := graphql.Cache[*ast.QueryDocument](lru.New[*ast.QueryDocument](cfg.Cache.Query.Size))
+
+	var apqCache graphql.Cache[string]
+	if apq := cfg.Cache.APQ; apq.Backend == "redis" {
+		apqCache = cache.NewRedis(redisClient(), apq.KeyPrefix, apq.TTL, apq.Size, logger)
+	} else {
+		apqCache = lru.New[string](apq.Size)
+	}
+
+	var manifest *persisted.Manifest
+	if po := cfg.PersistedOperations; po.Enabled {
+		if manifest, err = persisted.Load(po.Manifest); err != nil {
+			logger.Fatal("load persisted operations", zap.Error(err))
+		}
+		if po.HotReload {
+			if err := manifest.Watch(ctx, logger); err != nil {
+				logger.Fatal("watch persisted operations", zap.Error(err))
+			}
+		}
+	}
+
+	srv.Use(tracing.Extension{SkipTrivialFields: cfg.Tracing.SkipTrivialFields})
+
+	var m *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		m = metrics.New()
+		queryCache = metrics.InstrumentCache(m, "query", queryCache)
+		apqCache = metrics.InstrumentCache(m, "apq", apqCache)
+		srv.Use(metrics.Extension{
+			Metrics:            m,
+			ResolverSampleRate: cfg.Metrics.ResolverSampleRate,
+			KnownOperation: func(name string) bool {
+				return slices.Contains(cfg.Metrics.OperationNames, name) ||
+					manifest != nil && manifest.HasOperation(name)
+			},
+			ErrorCodes: errorCodes,
+		})
+	}
+
+	srv.SetQueryCache(queryCache)
 
 	srv.Use(extension.Introspection{})
-	srv.Use(extension.AutomaticPersistedQuery{
-		Cache: lru.New[string](100),
+	srv.Use(oplog.Extension{
+		Logger:                logger,
+		SlowResolverThreshold: cfg.GraphQL.SlowResolverThreshold,
+	})
+	srv.Use(limits.New(limits.Limits{
+		MaxDepth:      cfg.GraphQL.MaxDepth,
+		MaxComplexity: cfg.GraphQL.MaxComplexity,
+		MaxAliases:    cfg.GraphQL.MaxAliases,
+		MaxRootFields: cfg.GraphQL.MaxRootFields,
+	}))
+	srv.Use(&constraint.Extension{})
+	srv.Use(dataloader.Extension{Store: db})
+	// Strict persisted operations replace automatic persisted queries,
+	// which would let any client register new documents.
+	strictOperations := false
+	if po := cfg.PersistedOperations; po.Enabled {
+		strictOperations = po.Strict || cfg.Server.Mode == "production"
+		srv.Use(persisted.Extension{Manifest: manifest, Strict: strictOperations})
+		logger.Info("loaded persisted operations",
+			zap.Int("operations", manifest.Len()),
+			zap.Bool("strict", strictOperations),
+		)
+	}
+	if !strictOperations {
+		srv.Use(extension.AutomaticPersistedQuery{
+			Cache: apqCache,
+		})
+	}
+	srv.Use(&cachecontrol.Extension{})
+	if cfg.Cache.Response.Enabled {
+		responses := cachecontrol.NewResponseCache(cfg.Cache.Response.Size)
+		resolver.Responses = responses
+		srv.Use(responses)
+	}
+	if cfg.RateLimit.Enabled {
+		var limiter ratelimit.Limiter = ratelimit.NewMemory(nil)
+		if cfg.RateLimit.Backend == "redis" {
+			limiter = ratelimit.NewRedis(redisClient(), cfg.RateLimit.KeyPrefix, nil)
+		}
+		srv.Use(ratelimit.Extension{
+			Limiter: limiter,
+			Policy:  rateLimitPolicy(cfg.RateLimit),
+			Logger:  logger,
+		})
+	}
+
+	checks := health.NewRegistry()
+	checks.Register("store", 2*time.Second, db.Ping)
+	if rdb != nil {
+		checks.Register("redis", 2*time.Second, func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		})
+	}
+	if s3, ok := files.(*storage.S3); ok {
+		checks.Register("storage", 2*time.Second, s3.Ping)
+	}
+
+	// Uploads to /query are capped by the multipart transport's
+	// MaxUploadSize instead of http.max_body_bytes.
+	router := httpserver.NewRouter(cfg.HTTP, "/query")
+	router.Handle("/healthz", health.LivenessHandler())
+	router.Handle("/readyz", checks.ReadinessHandler())
+	if m != nil {
+		router.Handle(cfg.Metrics.Path, m.Handler())
+	}
+	if local, ok := files.(*storage.Local); ok {
+		router.Handle(local.Prefix()+"*", local.Handler())
+	}
+	var query http.Handler = auth.Middleware(verifier, cachecontrol.Middleware(srv))
+	if cfg.RateLimit.Enabled {
+		query = ratelimit.Middleware(cfg.RateLimit.APIKeyHeader)(query)
+	}
+	router.Handle("/query", query)
+
+	ideKind := ide.Kind(cfg.IDE.Kind)
+	if ideKind == "" {
+		ideKind = ide.GraphiQL
+		if cfg.Server.Mode == "production" {
+			ideKind = ide.Off
+		}
+	}
+	if ideKind != ide.Off {
+		var tabs []ide.Tab
+		if dir := cfg.IDE.ExamplesDir; dir != "" {
+			if tabs, err = ide.LoadTabs(dir); err != nil {
+				logger.Fatal("load ide examples", zap.Error(err))
+			}
+		}
+		ideHandler, err := ide.Handler(ideKind, ide.Options{
+			Title:    cfg.IDE.Title,
+			Endpoint: "/query",
+			Headers:  cfg.IDE.Headers,
+			Tabs:     tabs,
+			Logger:   logger,
+		})
+		if err != nil {
+			logger.Fatal("configure ide", zap.Error(err))
+		}
+		router.Handle(cfg.IDE.Path, ideHandler)
+		logger.Info("serving GraphQL IDE",
+			zap.String("ide", string(ideKind)),
+			zap.String("url", "http://localhost:"+cfg.Server.Port+cfg.IDE.Path),
+			zap.Int("examples", len(tabs)),
+		)
+	}
+
+	httpServer := httpserver.New(cfg.Server, router, logger)
+	httpServer.OnDrain(checks.Drain)
+	httpServer.OnShutdown("pubsub", func(context.Context) error {
+		events.Close()
+		return nil
+	})
+	httpServer.OnShutdown("store", func(context.Context) error {
+		return db.Close()
 	})
+	if rdb != nil {
+		httpServer.OnShutdown("redis", func(context.Context) error {
+			return rdb.Close()
+		})
+	}
+	httpServer.OnShutdown("tracing", shutdownTracing)
 
-	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
-	http.Handle("/query", srv)
+	logger.Info("listening", zap.String("port", cfg.Server.Port))
+	if err := httpServer.Run(ctx); err != nil {
+		logger.Fatal("server stopped", zap.Error(err))
+	}
+}
+
+// errorCodes are every extensions.code the server sets.
+var errorCodes = []string{
+	string(apperr.CodeNotFound),
+	string(apperr.CodeValidation),
+	string(apperr.CodeConflict),
+	string(apperr.CodeUnauthenticated),
+	string(apperr.CodeForbidden),
+	string(apperr.CodeInternal),
+	errcode.ParseFailed,
+	errcode.ValidationFailed,
+	limits.CodeDepthLimit,
+	limits.CodeComplexityLimit,
+	limits.CodeAliasLimit,
+	limits.CodeRootFieldLimit,
+	persisted.CodeNotFound,
+	persisted.CodeNotAllowed,
+	ratelimit.CodeRateLimited,
+}
 
-	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+// fileStorage opens the storage configured for attachment content.
+func fileStorage(cfg config.UploadsConfig) (storage.Storage, error) {
+	switch cfg.Storage {
+	case "local", "":
+		return storage.NewLocal(storage.LocalOptions{
+			Dir:       cfg.Local.Dir,
+			URLPrefix: cfg.Local.URLPrefix,
+			URLSecret: cfg.Local.URLSecret,
+			URLExpiry: cfg.Local.URLExpiry,
+		})
+	case "s3":
+		return storage.NewS3(storage.S3Options{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			UseSSL:    cfg.S3.UseSSL,
+			URLExpiry: cfg.S3.URLExpiry,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage %q", cfg.Storage)
+	}
+}
+
+// rateLimitPolicy converts the configured rates, charging operations by
+// complexity as measured by the limits extension.
+func rateLimitPolicy(cfg config.RateLimitConfig) ratelimit.Policy {
+	rate := func(r config.RateLimitRate) ratelimit.Rate {
+		return ratelimit.Rate{Requests: r.Requests, Period: r.Period, Burst: r.Burst}
+	}
+	policy := ratelimit.Policy{
+		User:        rate(cfg.User),
+		APIKey:      rate(cfg.APIKey),
+		IP:          rate(cfg.IP),
+		KnownAPIKey: ratelimit.APIKeys(cfg.APIKeys),
+	}
+	if unit := cfg.ComplexityUnit; unit > 0 {
+		policy.Weight = func(opCtx *graphql.OperationContext) int {
+			stats := limits.OperationStats(opCtx)
+			if stats == nil {
+				return 1
+			}
+			return max(1, (stats.Complexity+unit-1)/unit)
+		}
+	}
+	return policy
 }
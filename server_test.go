@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/natnael_wondwoesn/GGStarter/graph"
+	"github.com/natnael_wondwoesn/GGStarter/graph/limits"
+	"github.com/natnael_wondwoesn/GGStarter/graph/model"
+	"github.com/natnael_wondwoesn/GGStarter/internal/metrics"
+	"github.com/natnael_wondwoesn/GGStarter/internal/pubsub"
+	"github.com/natnael_wondwoesn/GGStarter/internal/store"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestLimitErrorsAreCountedByCode checks that every limit violation is
+// recorded under its own code rather than metrics.OtherErrorCode.
+func TestLimitErrorsAreCountedByCode(t *testing.T) {
+	tests := []struct {
+		code   string
+		limits limits.Limits
+		query  string
+	}{
+		{limits.CodeDepthLimit, limits.Limits{MaxDepth: 1}, `{ todos { id } }`},
+		{limits.CodeComplexityLimit, limits.Limits{MaxComplexity: 1}, `{ todos { id } }`},
+		{limits.CodeAliasLimit, limits.Limits{MaxAliases: 1}, `{ a: todos { id } b: todos { id } }`},
+		{limits.CodeRootFieldLimit, limits.Limits{MaxRootFields: 1}, `{ todos { id } users { id } }`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			m := metrics.New()
+			cfg := graph.Config{Resolvers: &graph.Resolver{Store: store.NewMemory(), Events: pubsub.NewHub[*model.Todo]()}}
+			graph.SetComplexity(&cfg.Complexity)
+			srv := handler.New(graph.NewExecutableSchema(cfg))
+			srv.AddTransport(transport.POST{})
+			srv.Use(metrics.Extension{Metrics: m, ErrorCodes: errorCodes})
+			srv.Use(limits.New(tt.limits))
+
+			body, err := json.Marshal(map[string]string{"query": tt.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			srv.ServeHTTP(httptest.NewRecorder(), req)
+
+			want := fmt.Sprintf(`
+# HELP graphql_errors_total GraphQL errors returned to clients, by extensions.code.
+# TYPE graphql_errors_total counter
+graphql_errors_total{code=%q} 1
+`, tt.code)
+			if err := testutil.GatherAndCompare(m.Registry(), strings.NewReader(want), "graphql_errors_total"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}